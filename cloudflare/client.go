@@ -4,10 +4,14 @@
 package cloudflare
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/url"
@@ -61,6 +65,23 @@ type Options struct {
 	// GlobalKey and Email for legacy auth.
 	GlobalKey string
 	Email     string
+	// Credentials, if set, supplies the credentials of every request instead
+	// of APIToken, GlobalKey and Email.
+	Credentials CredentialProvider
+	// RetryPolicy configures retries of transient failures; zero disables retries.
+	RetryPolicy RetryPolicy
+	// RateLimit (requests per second) and RateBurst configure the client-side limiter.
+	RateLimit float64
+	RateBurst int
+	// DryRun simulates mutating requests instead of sending them.
+	DryRun bool
+	// Middleware wraps every attempt, the first entry outermost.
+	Middleware []Middleware
+	// Tracer and Meter instrument every API call; nil disables them.
+	Tracer Tracer
+	Meter  Meter
+	// Logger receives debug logs of requests and responses; nil disables them.
+	Logger *slog.Logger
 }
 
 // Option is a functional option for configuring Options.
@@ -91,16 +112,21 @@ func WithGlobalKey(email, key string) Option {
 
 // Client is a Cloudflare API client.
 type Client struct {
-	authMode   AuthMode
-	email      string
-	globalKey  string
-	apiToken   string
+	creds      CredentialProvider
 	baseURL    *url.URL
 	httpClient *http.Client
 	userAgent  string
+	retry      RetryPolicy
+	limiter    *rateLimiter
+	dryRun     bool
+	roundTrip  RoundTripFunc
+	tracer     Tracer
+	meter      Meter
+	logger     *slog.Logger
 }
 
-// New constructs a new Cloudflare client. Exactly one of (email+globalKey) or (apiToken) must be provided.
+// New constructs a new Cloudflare client. Exactly one of (email+globalKey), (apiToken) or a
+// credential provider must be provided.
 // Example:
 //
 //	New("user@example.com", "<global-key>")
@@ -111,19 +137,24 @@ func New(opts ...Option) (*Client, error) {
 		opt(&options)
 	}
 
-	// Determine auth mode and validate mutual exclusivity
-	var mode AuthMode
-	haveGlobal := options.Email != "" && options.GlobalKey != ""
-	haveToken := options.APIToken != ""
-	if haveGlobal && haveToken {
-		return nil, errors.New("invalid auth: specify either global key (with email) or api token, not both")
-	}
-	if haveGlobal {
-		mode = AuthGlobalKey
-	} else if haveToken {
-		mode = AuthAPIToken
+	// Validate fixed credentials up front; a provider is consulted per request.
+	creds := options.Credentials
+	static := Credentials{APIToken: options.APIToken, Email: options.Email, GlobalKey: options.GlobalKey}
+	if creds != nil {
+		if static != (Credentials{}) {
+			return nil, errors.New("invalid auth: specify either a credential provider or fixed credentials, not both")
+		}
 	} else {
-		return nil, errors.New("missing auth: provide global key+email or api token")
+		mode, err := static.mode()
+		if err != nil {
+			return nil, err
+		}
+		if mode == AuthAPIToken {
+			static.Email, static.GlobalKey = "", ""
+		} else {
+			static.APIToken = ""
+		}
+		creds = StaticCredentials(static)
 	}
 
 	base := options.BaseURL
@@ -159,42 +190,108 @@ func New(opts ...Option) (*Client, error) {
 		userAgent = defaultUserAgent
 	}
 
+	middleware := options.Middleware
+	if options.Logger != nil {
+		middleware = append(middleware[:len(middleware):len(middleware)], logMiddleware(options.Logger))
+	}
+
 	c := &Client{
-		authMode:   mode,
-		email:      options.Email,
-		globalKey:  options.GlobalKey,
+		creds:      creds,
 		baseURL:    parsed,
 		httpClient: httpClient,
 		userAgent:  userAgent,
+		retry:      options.RetryPolicy,
+		limiter:    newRateLimiter(options.RateLimit, options.RateBurst),
+		dryRun:     options.DryRun,
+		roundTrip:  chain(middleware, httpClient.Do),
+		tracer:     options.Tracer,
+		meter:      options.Meter,
+		logger:     options.Logger,
 	}
-	if mode == AuthAPIToken {
-		c.apiToken = options.APIToken
-	}
-
 	return c, nil
 }
 
-// do sends an HTTP request to the Cloudflare API with proper headers and context.
+// do sends an HTTP request to the Cloudflare API with proper headers and context,
+// retrying transient failures according to the client's RetryPolicy. Every
+// attempt waits for the client-side rate limiter, if configured, and then
+// passes through the middleware chain. In dry-run mode mutating requests are
+// simulated instead. With a Tracer or Meter the call is instrumented.
 func (c *Client) do(ctx context.Context, req *http.Request) (*http.Response, error) {
-	req = req.Clone(ctx)
-	req.Header.Set(headerContentType, "application/json")
-	req.Header.Set(headerUserAgent, c.userAgent)
-	switch c.authMode {
-	case AuthGlobalKey:
-		if c.email == "" || c.globalKey == "" {
-			return nil, errors.New("missing global key credentials")
+	if c.tracer != nil || c.meter != nil {
+		return c.instrument(ctx, req)
+	}
+	resp, _, err := c.send(ctx, req, SpanContext{})
+	return resp, err
+}
+
+// send implements do, propagating sc (if valid) as the traceparent of every
+// attempt. It also returns the number of retries made.
+func (c *Client) send(ctx context.Context, req *http.Request, sc SpanContext) (*http.Response, int, error) {
+	if c.dryRun && mutating(req.Method) {
+		resp, err := c.simulate(ctx, req)
+		return resp, 0, err
+	}
+	for attempt := 0; ; attempt++ {
+		r, err := c.prepare(ctx, req, attempt)
+		if err != nil {
+			return nil, attempt, err
 		}
-		req.Header.Set(headerAuthEmail, c.email)
-		req.Header.Set(headerAuthKey, c.globalKey)
-	case AuthAPIToken:
-		if c.apiToken == "" {
-			return nil, errors.New("missing API token")
+		if sc.IsValid() {
+			r.Header.Set(headerTraceParent, sc.TraceParent())
+		}
+		if err := c.limiter.wait(ctx); err != nil {
+			return nil, attempt, err
+		}
+		resp, err := c.roundTrip(r)
+		c.limiter.observe(resp)
+		if attempt >= c.retry.MaxRetries || !c.retry.retryable(ctx, req, resp, err) {
+			return resp, attempt, err
+		}
+		wait := c.retry.backoff(attempt, resp)
+		if err != nil {
+			c.debug(ctx, "retrying api request", "method", req.Method, "url", req.URL.String(), "attempt", attempt+1, "wait", wait, "error", err)
+		} else {
+			c.debug(ctx, "retrying api request", "method", req.Method, "url", req.URL.String(), "attempt", attempt+1, "wait", wait, "status", resp.StatusCode)
+		}
+		if !sleepCtx(ctx, wait) {
+			return resp, attempt, err
+		}
+		drainBody(resp)
+	}
+}
+
+// prepare clones req for a single attempt, rewinding the body on retries and
+// setting the content, user agent and auth headers. Credentials are resolved
+// for every attempt.
+func (c *Client) prepare(ctx context.Context, req *http.Request, attempt int) (*http.Request, error) {
+	r := req.Clone(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
 		}
-		req.Header.Set(headerAuthz, "Bearer "+c.apiToken)
-	default:
-		return nil, errors.New("unknown auth mode")
+		r.Body = body
+	}
+	if r.Header.Get(headerContentType) == "" {
+		r.Header.Set(headerContentType, "application/json")
+	}
+	r.Header.Set(headerUserAgent, c.userAgent)
+	creds, err := c.creds.Credentials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("resolving credentials: %w", err)
+	}
+	mode, err := creds.mode()
+	if err != nil {
+		return nil, err
 	}
-	return c.httpClient.Do(req)
+	switch mode {
+	case AuthGlobalKey:
+		r.Header.Set(headerAuthEmail, creds.Email)
+		r.Header.Set(headerAuthKey, creds.GlobalKey)
+	case AuthAPIToken:
+		r.Header.Set(headerAuthz, "Bearer "+creds.APIToken)
+	}
+	return r, nil
 }
 
 // buildURL joins the base URL with the given path (which may start with '/').
@@ -203,3 +300,41 @@ func (c *Client) buildURL(p string) string {
 	rel, _ := url.Parse(p)
 	return c.baseURL.ResolveReference(rel).String()
 }
+
+// request sends method to path with payload JSON-encoded as the body (if non-nil)
+// and decodes the Cloudflare response envelope. Non-2xx statuses and envelopes
+// with success=false are returned as *APIError.
+func request[T any](ctx context.Context, c *Client, method, path string, payload any) (*apiResponse[T], error) {
+	var body io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.buildURL(path), body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return decodeResponse[T](req, resp)
+}
+
+// decodeResponse decodes the Cloudflare envelope of resp, returning *APIError
+// for non-2xx statuses and unsuccessful envelopes.
+func decodeResponse[T any](req *http.Request, resp *http.Response) (*apiResponse[T], error) {
+	var out apiResponse[T]
+	decodeErr := json.NewDecoder(resp.Body).Decode(&out)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || (decodeErr == nil && !out.Success) {
+		return nil, newAPIError(req, resp, out.Errors, out.Messages)
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	return &out, nil
+}
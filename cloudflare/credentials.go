@@ -0,0 +1,362 @@
+package cloudflare
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables read by EnvCredentials. Each may instead be given as
+// a file through the same name with a _FILE suffix, e.g. CF_API_TOKEN_FILE.
+const (
+	EnvAPIToken  = "CF_API_TOKEN"
+	EnvEmail     = "CF_EMAIL"
+	EnvGlobalKey = "CF_GLOBAL_KEY"
+	// EnvCredentialsFile overrides the path of the profiles file.
+	EnvCredentialsFile = "CF_CREDENTIALS_FILE"
+)
+
+// DefaultProfile is the profile ProfileCredentials reads when none is named.
+const DefaultProfile = "default"
+
+// processRefreshMargin is how long before their expiry credentials returned
+// by a credential process are refreshed.
+const processRefreshMargin = time.Minute
+
+// ErrNoCredentials is returned by a CredentialProvider that has no
+// credentials to offer; ChainCredentials moves on to the next provider.
+var ErrNoCredentials = errors.New("no credentials")
+
+// Credentials authenticate API requests: an API token, or an email and
+// Global API Key.
+type Credentials struct {
+	APIToken  string `json:"api_token,omitempty"`
+	Email     string `json:"email,omitempty"`
+	GlobalKey string `json:"global_key,omitempty"`
+}
+
+// mode validates the credentials and returns how they authenticate.
+func (c Credentials) mode() (AuthMode, error) {
+	haveGlobal := c.Email != "" && c.GlobalKey != ""
+	haveToken := c.APIToken != ""
+	switch {
+	case haveGlobal && haveToken:
+		return 0, errors.New("invalid auth: specify either global key (with email) or api token, not both")
+	case haveGlobal:
+		return AuthGlobalKey, nil
+	case haveToken:
+		return AuthAPIToken, nil
+	}
+	return 0, errors.New("missing auth: provide global key+email or api token")
+}
+
+// CredentialProvider supplies the credentials of every request attempt, so
+// rotated secrets are picked up without recreating the client.
+// Implementations must be safe for concurrent use and should return an error
+// wrapping ErrNoCredentials when they have nothing to offer.
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// CredentialProviderFunc adapts a function to a CredentialProvider.
+type CredentialProviderFunc func(ctx context.Context) (Credentials, error)
+
+// Credentials implements CredentialProvider.
+func (f CredentialProviderFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+// WithCredentials resolves the credentials of every request from p instead
+// of fixed values; it cannot be combined with WithAPIToken or WithGlobalKey.
+func WithCredentials(p CredentialProvider) Option {
+	return func(o *Options) { o.Credentials = p }
+}
+
+// StaticCredentials always returns creds.
+func StaticCredentials(creds Credentials) CredentialProvider {
+	return CredentialProviderFunc(func(context.Context) (Credentials, error) { return creds, nil })
+}
+
+// ChainCredentials returns the credentials of the first provider that has
+// any, skipping those that return ErrNoCredentials. Other errors stop the
+// chain.
+func ChainCredentials(providers ...CredentialProvider) CredentialProvider {
+	return CredentialProviderFunc(func(ctx context.Context) (Credentials, error) {
+		for _, p := range providers {
+			creds, err := p.Credentials(ctx)
+			if errors.Is(err, ErrNoCredentials) {
+				continue
+			}
+			return creds, err
+		}
+		return Credentials{}, ErrNoCredentials
+	})
+}
+
+// EnvCredentials reads CF_API_TOKEN, CF_EMAIL and CF_GLOBAL_KEY, or the files
+// named by CF_API_TOKEN_FILE, CF_EMAIL_FILE and CF_GLOBAL_KEY_FILE (as
+// mounted for Docker and Kubernetes secrets), on every call.
+func EnvCredentials() CredentialProvider {
+	return CredentialProviderFunc(func(context.Context) (Credentials, error) {
+		var creds Credentials
+		var err error
+		if creds.APIToken, err = envOrFile(EnvAPIToken); err != nil {
+			return Credentials{}, err
+		}
+		if creds.Email, err = envOrFile(EnvEmail); err != nil {
+			return Credentials{}, err
+		}
+		if creds.GlobalKey, err = envOrFile(EnvGlobalKey); err != nil {
+			return Credentials{}, err
+		}
+		if creds == (Credentials{}) {
+			return Credentials{}, fmt.Errorf("%w in environment", ErrNoCredentials)
+		}
+		return creds, nil
+	})
+}
+
+// envOrFile returns the value of the environment variable key, or the
+// trimmed contents of the file named by key_FILE.
+func envOrFile(key string) (string, error) {
+	v, file := os.Getenv(key), os.Getenv(key+"_FILE")
+	switch {
+	case file == "":
+		return v, nil
+	case v != "":
+		return "", fmt.Errorf("set either %s or %s_FILE, not both", key, key)
+	}
+	b, err := os.ReadFile(file) // #nosec G304 -- path is provided by the operator
+	if err != nil {
+		return "", fmt.Errorf("%s_FILE: %w", key, err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// DefaultCredentialsFile returns the path of the profiles file:
+// CF_CREDENTIALS_FILE, else cloudflare/credentials under $XDG_CONFIG_HOME or
+// ~/.config.
+func DefaultCredentialsFile() (string, error) {
+	if p := os.Getenv(EnvCredentialsFile); p != "" {
+		return p, nil
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "cloudflare", "credentials"), nil
+}
+
+// ProfileCredentials reads a profile from an INI-style credentials file
+// (DefaultCredentialsFile if path is empty) on every call:
+//
+//	[default]
+//	api_token = <token>
+//
+//	[legacy]
+//	email = ops@example.com
+//	global_key = <key>
+//
+//	[vault]
+//	credential_process = vault kv get -format=json -field=data secret/cloudflare
+//
+// Keys are api_token, email, global_key and credential_process (see
+// ProcessCredentials). Lines starting with # or ; are comments. An empty
+// profile means DefaultProfile; a missing file, or a missing default
+// profile, yields ErrNoCredentials, while a missing named profile is an
+// error.
+func ProfileCredentials(path, profile string) CredentialProvider {
+	return &profileProvider{path: path, profile: profile, processes: map[string]*processProvider{}}
+}
+
+type profileProvider struct {
+	path    string
+	profile string
+
+	mu sync.Mutex
+	// processes keeps one provider per command so their results are cached
+	// across calls.
+	processes map[string]*processProvider
+}
+
+// Credentials implements CredentialProvider.
+func (p *profileProvider) Credentials(ctx context.Context) (Credentials, error) {
+	path := p.path
+	if path == "" {
+		var err error
+		if path, err = DefaultCredentialsFile(); err != nil {
+			return Credentials{}, fmt.Errorf("%w: %v", ErrNoCredentials, err)
+		}
+	}
+	name := p.profile
+	if name == "" {
+		name = DefaultProfile
+	}
+	b, err := os.ReadFile(path) // #nosec G304 -- path is provided by the operator
+	if errors.Is(err, fs.ErrNotExist) && p.profile == "" {
+		return Credentials{}, fmt.Errorf("%w: %s does not exist", ErrNoCredentials, path)
+	}
+	if err != nil {
+		return Credentials{}, err
+	}
+	profiles, err := parseProfiles(b)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("%s: %w", path, err)
+	}
+	prof, ok := profiles[name]
+	switch {
+	case !ok && p.profile == "":
+		return Credentials{}, fmt.Errorf("%w: no profile %q in %s", ErrNoCredentials, name, path)
+	case !ok:
+		return Credentials{}, fmt.Errorf("%s: profile %q not found", path, name)
+	}
+	if prof.process == "" {
+		return prof.Credentials, nil
+	}
+	if prof.Credentials != (Credentials{}) {
+		return Credentials{}, fmt.Errorf("%s: profile %q: set either credential_process or credentials, not both", path, name)
+	}
+	p.mu.Lock()
+	proc, ok := p.processes[prof.process]
+	if !ok {
+		proc = &processProvider{command: prof.process}
+		p.processes[prof.process] = proc
+	}
+	p.mu.Unlock()
+	return proc.Credentials(ctx)
+}
+
+// profile is one section of the credentials file.
+type profile struct {
+	Credentials
+	process string
+}
+
+// parseProfiles parses the INI-style credentials file.
+func parseProfiles(b []byte) (map[string]profile, error) {
+	profiles := map[string]profile{}
+	var (
+		section string
+		errs    []error
+	)
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.TrimSpace(line[1:len(line)-1]) == "" {
+				errs = append(errs, fmt.Errorf("line %d: invalid section header %q", n, line))
+				continue
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := profiles[section]; ok {
+				errs = append(errs, fmt.Errorf("line %d: duplicate profile %q", n, section))
+			}
+			profiles[section] = profile{}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			errs = append(errs, fmt.Errorf("line %d: expected key = value", n))
+			continue
+		}
+		if section == "" {
+			errs = append(errs, fmt.Errorf("line %d: key outside of a [profile] section", n))
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		prof := profiles[section]
+		switch key {
+		case "api_token":
+			prof.APIToken = value
+		case "email":
+			prof.Email = value
+		case "global_key":
+			prof.GlobalKey = value
+		case "credential_process":
+			prof.process = value
+		default:
+			errs = append(errs, fmt.Errorf("line %d: unknown key %q", n, key))
+		}
+		profiles[section] = prof
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return profiles, errors.Join(errs...)
+}
+
+// ProcessCredentials runs command through the shell (sh -c, or cmd /C on
+// Windows) and decodes the credentials it prints on stdout as JSON:
+//
+//	{"api_token": "<token>", "expires_at": "2026-01-02T15:04:05Z"}
+//
+// email and global_key may be returned instead of api_token. The result is
+// reused until a minute before expires_at (RFC 3339); without expires_at the
+// command runs for every request. Its stderr is included in errors.
+func ProcessCredentials(command string) CredentialProvider {
+	return &processProvider{command: command}
+}
+
+type processProvider struct {
+	command string
+
+	mu      sync.Mutex
+	cached  Credentials
+	expires time.Time
+}
+
+// processOutput is the JSON printed by a credential process.
+type processOutput struct {
+	Credentials
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Credentials implements CredentialProvider.
+func (p *processProvider) Credentials(ctx context.Context) (Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Now().Before(p.expires) {
+		return p.cached, nil
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", p.command) // #nosec G204 -- command is configured by the operator
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", p.command) // #nosec G204 -- command is configured by the operator
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return Credentials{}, fmt.Errorf("credential process: %w: %s", err, msg)
+		}
+		return Credentials{}, fmt.Errorf("credential process: %w", err)
+	}
+	var out processOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return Credentials{}, fmt.Errorf("credential process: decoding output: %w", err)
+	}
+	if _, err := out.mode(); err != nil {
+		return Credentials{}, fmt.Errorf("credential process: %w", err)
+	}
+	p.cached, p.expires = out.Credentials, out.ExpiresAt.Add(-processRefreshMargin)
+	return out.Credentials, nil
+}
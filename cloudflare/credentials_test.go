@@ -0,0 +1,189 @@
+package cloudflare_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+// authServer records the Authorization header of every request.
+func authServer(t *testing.T) (url string, seen func() []string) {
+	t.Helper()
+	var got []string
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+		zoneOK(w)
+	})
+	t.Cleanup(srv.Close)
+	return srv.URL, func() []string { return got }
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestWithCredentials_ResolvedPerRequest(t *testing.T) {
+	url, seen := authServer(t)
+	token := writeFile(t, "token", "first\n")
+	t.Setenv("CF_API_TOKEN", "")
+	t.Setenv("CF_API_TOKEN_FILE", token)
+
+	c := mustClient(t, cloudflare.WithBaseURL(url), cloudflare.WithCredentials(cloudflare.EnvCredentials()))
+	_, err := c.FindZoneID(context.Background(), "example.com")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(token, []byte("rotated"), 0o600))
+	_, err = c.FindZoneID(context.Background(), "example.com")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Bearer first", "Bearer rotated"}, seen())
+}
+
+func TestWithCredentials_ExclusiveWithFixedCredentials(t *testing.T) {
+	_, err := cloudflare.New(cloudflare.WithAPIToken("tok"),
+		cloudflare.WithCredentials(cloudflare.StaticCredentials(cloudflare.Credentials{APIToken: "tok"})))
+	require.ErrorContains(t, err, "not both")
+}
+
+func TestWithCredentials_ProviderErrorFailsRequest(t *testing.T) {
+	url, seen := authServer(t)
+	c := mustClient(t, cloudflare.WithBaseURL(url), cloudflare.WithCredentials(cloudflare.ChainCredentials()))
+	_, err := c.FindZoneID(context.Background(), "example.com")
+	require.ErrorIs(t, err, cloudflare.ErrNoCredentials)
+	require.Empty(t, seen())
+}
+
+func TestEnvCredentials(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("CF_API_TOKEN", "")
+	t.Setenv("CF_API_TOKEN_FILE", "")
+	t.Setenv("CF_EMAIL", "me@example.com")
+	t.Setenv("CF_GLOBAL_KEY_FILE", writeFile(t, "key", " key-value \n"))
+	creds, err := cloudflare.EnvCredentials().Credentials(ctx)
+	require.NoError(t, err)
+	require.Equal(t, cloudflare.Credentials{Email: "me@example.com", GlobalKey: "key-value"}, creds)
+
+	t.Setenv("CF_GLOBAL_KEY", "other")
+	_, err = cloudflare.EnvCredentials().Credentials(ctx)
+	require.ErrorContains(t, err, "set either CF_GLOBAL_KEY or CF_GLOBAL_KEY_FILE")
+
+	t.Setenv("CF_EMAIL", "")
+	t.Setenv("CF_GLOBAL_KEY", "")
+	t.Setenv("CF_GLOBAL_KEY_FILE", "")
+	_, err = cloudflare.EnvCredentials().Credentials(ctx)
+	require.ErrorIs(t, err, cloudflare.ErrNoCredentials)
+}
+
+func TestProfileCredentials(t *testing.T) {
+	ctx := context.Background()
+	path := writeFile(t, "credentials", `
+# Cloudflare credentials
+[default]
+api_token = default-token
+
+[legacy]
+email = ops@example.com
+; the Global API Key
+global_key = legacy-key
+`)
+	creds, err := cloudflare.ProfileCredentials(path, "").Credentials(ctx)
+	require.NoError(t, err)
+	require.Equal(t, cloudflare.Credentials{APIToken: "default-token"}, creds)
+
+	creds, err = cloudflare.ProfileCredentials(path, "legacy").Credentials(ctx)
+	require.NoError(t, err)
+	require.Equal(t, cloudflare.Credentials{Email: "ops@example.com", GlobalKey: "legacy-key"}, creds)
+
+	_, err = cloudflare.ProfileCredentials(path, "missing").Credentials(ctx)
+	require.ErrorContains(t, err, `profile "missing" not found`)
+	require.NotErrorIs(t, err, cloudflare.ErrNoCredentials)
+
+	_, err = cloudflare.ProfileCredentials(filepath.Join(t.TempDir(), "none"), "").Credentials(ctx)
+	require.ErrorIs(t, err, cloudflare.ErrNoCredentials)
+}
+
+func TestProfileCredentials_InvalidFile(t *testing.T) {
+	path := writeFile(t, "credentials", "api_token = x\n[default]\ntoken = y\n[default\n")
+	_, err := cloudflare.ProfileCredentials(path, "").Credentials(context.Background())
+	require.ErrorContains(t, err, "line 1: key outside of a [profile] section")
+	require.ErrorContains(t, err, `line 3: unknown key "token"`)
+	require.ErrorContains(t, err, `line 4: invalid section header "[default"`)
+}
+
+func TestProcessCredentials_CachedUntilExpiry(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	count := filepath.Join(dir, "count")
+	script := func(expires string) string {
+		return `echo run >> ` + count + `; echo '{"api_token": "from-process", "expires_at": "` + expires + `"}'`
+	}
+	runs := func() int {
+		b, _ := os.ReadFile(count)
+		return strings.Count(string(b), "run")
+	}
+
+	p := cloudflare.ProcessCredentials(script("2999-01-01T00:00:00Z"))
+	for range 2 {
+		creds, err := p.Credentials(ctx)
+		require.NoError(t, err)
+		require.Equal(t, cloudflare.Credentials{APIToken: "from-process"}, creds)
+	}
+	require.Equal(t, 1, runs())
+
+	p = cloudflare.ProcessCredentials(script("2000-01-01T00:00:00Z"))
+	for range 2 {
+		_, err := p.Credentials(ctx)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 3, runs())
+}
+
+func TestProcessCredentials_Failure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	_, err := cloudflare.ProcessCredentials("echo vault is sealed >&2; exit 3").Credentials(context.Background())
+	require.ErrorContains(t, err, "exit status 3: vault is sealed")
+
+	_, err = cloudflare.ProcessCredentials(`echo '{}'`).Credentials(context.Background())
+	require.ErrorContains(t, err, "missing auth")
+}
+
+func TestProfileCredentials_CredentialProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	path := writeFile(t, "credentials", "[ci]\ncredential_process = echo '{\"api_token\": \"ci-token\"}'\n")
+	creds, err := cloudflare.ProfileCredentials(path, "ci").Credentials(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, cloudflare.Credentials{APIToken: "ci-token"}, creds)
+}
+
+func TestChainCredentials_FirstAvailableWins(t *testing.T) {
+	none := cloudflare.CredentialProviderFunc(func(context.Context) (cloudflare.Credentials, error) {
+		return cloudflare.Credentials{}, cloudflare.ErrNoCredentials
+	})
+	broken := cloudflare.CredentialProviderFunc(func(context.Context) (cloudflare.Credentials, error) {
+		return cloudflare.Credentials{}, errors.New("unreadable")
+	})
+	token := cloudflare.StaticCredentials(cloudflare.Credentials{APIToken: "tok"})
+
+	creds, err := cloudflare.ChainCredentials(none, token, broken).Credentials(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "tok", creds.APIToken)
+
+	_, err = cloudflare.ChainCredentials(none, broken, token).Credentials(context.Background())
+	require.EqualError(t, err, "unreadable")
+}
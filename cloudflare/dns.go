@@ -2,180 +2,294 @@ package cloudflare
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
 // API response wrappers
 type apiResponse[T any] struct {
-	Success  bool         `json:"success"`
-	Errors   []apiMessage `json:"errors"`
-	Messages []apiMessage `json:"messages"`
-	Result   T            `json:"result"`
-}
-
-type apiMessage struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Success    bool         `json:"success"`
+	Errors     []APIMessage `json:"errors"`
+	Messages   []APIMessage `json:"messages"`
+	Result     T            `json:"result"`
+	ResultInfo *ResultInfo  `json:"result_info,omitempty"`
 }
 
 // Zone represents a Cloudflare Zone
 type Zone struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Status      string   `json:"status,omitempty"`
+	NameServers []string `json:"name_servers,omitempty"`
+	// Permissions lists what the credentials may do in the zone, e.g.
+	// "#dns_records:edit", when Cloudflare reports it.
+	Permissions []string `json:"permissions,omitempty"`
 }
 
-// DNSRecord represents a DNS record
+// DNS record types supported by the DNS record methods.
+const (
+	RecordTypeA      = "A"
+	RecordTypeAAAA   = "AAAA"
+	RecordTypeCNAME  = "CNAME"
+	RecordTypeTXT    = "TXT"
+	RecordTypeMX     = "MX"
+	RecordTypeNS     = "NS"
+	RecordTypeSRV    = "SRV"
+	RecordTypeCAA    = "CAA"
+	RecordTypePTR    = "PTR"
+	RecordTypeHTTPS  = "HTTPS"
+	RecordTypeSVCB   = "SVCB"
+	RecordTypeTLSA   = "TLSA"
+	RecordTypeSSHFP  = "SSHFP"
+	RecordTypeNAPTR  = "NAPTR"
+	RecordTypeURI    = "URI"
+	RecordTypeDS     = "DS"
+	RecordTypeDNSKEY = "DNSKEY"
+	RecordTypeLOC    = "LOC"
+)
+
+// DNSRecord represents a DNS record of any type. Simple types use Content;
+// structured types (SRV, CAA, TLSA, LOC, ...) may use Data instead, keyed by
+// the field names of the Cloudflare API.
 type DNSRecord struct {
-	ID      string `json:"id,omitempty"`
-	Type    string `json:"type"`
-	Name    string `json:"name"`
-	Content string `json:"content"`
-	TTL     int    `json:"ttl"`
-	Proxied bool   `json:"proxied"`
+	ID       string         `json:"id,omitempty"`
+	Type     string         `json:"type"`
+	Name     string         `json:"name"`
+	Content  string         `json:"content,omitempty"`
+	TTL      int            `json:"ttl"`
+	Proxied  bool           `json:"proxied"`
+	Priority *uint16        `json:"priority,omitempty"`
+	Data     map[string]any `json:"data,omitempty"`
+	Comment  string         `json:"comment,omitempty"`
+	Tags     []string       `json:"tags,omitempty"`
+
+	// Read-only fields populated by the API.
+	Proxiable  bool   `json:"proxiable,omitempty"`
+	CreatedOn  string `json:"created_on,omitempty"`
+	ModifiedOn string `json:"modified_on,omitempty"`
 }
 
-// FindZoneID looks up the Zone ID by exact zone name.
-func (c *Client) FindZoneID(ctx context.Context, zoneName string) (string, error) {
-	if zoneName == "" {
-		return "", errors.New("zone name cannot be empty")
+// DNSRecordPatch holds the fields to change with PatchDNSRecord; nil fields are left untouched.
+type DNSRecordPatch struct {
+	Type     *string        `json:"type,omitempty"`
+	Name     *string        `json:"name,omitempty"`
+	Content  *string        `json:"content,omitempty"`
+	TTL      *int           `json:"ttl,omitempty"`
+	Proxied  *bool          `json:"proxied,omitempty"`
+	Priority *uint16        `json:"priority,omitempty"`
+	Data     map[string]any `json:"data,omitempty"`
+	Comment  *string        `json:"comment,omitempty"`
+	Tags     *[]string      `json:"tags,omitempty"`
+}
+
+// DNSRecordFilter narrows ListDNSRecords. Zero-valued fields are not sent.
+type DNSRecordFilter struct {
+	// Type matches the record type, e.g. "AAAA".
+	Type string
+	// Name matches the record FQDN exactly.
+	Name string
+	// Content matches the record content exactly.
+	Content string
+	// Proxied filters on the proxied flag when non-nil.
+	Proxied *bool
+	// Comment matches the record comment exactly.
+	Comment string
+	// Tag matches records carrying the tag ("name" or "name:value").
+	Tag string
+	// PerPage sets the page size used while iterating.
+	PerPage int
+}
+
+func (f DNSRecordFilter) values() url.Values {
+	params := url.Values{}
+	if f.Type != "" {
+		params.Set("type", strings.ToUpper(f.Type))
 	}
-	u := c.buildURL("zones?name=" + url.QueryEscape(zoneName))
-	req, err := http.NewRequest(http.MethodGet, u, nil)
-	if err != nil {
-		return "", err
+	if f.Name != "" {
+		params.Set("name", f.Name)
 	}
-	resp, err := c.do(ctx, req)
-	if err != nil {
-		return "", err
+	if f.Content != "" {
+		params.Set("content", f.Content)
+	}
+	if f.Proxied != nil {
+		params.Set("proxied", strconv.FormatBool(*f.Proxied))
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return "", fmt.Errorf("zones lookup failed: %s", resp.Status)
+	if f.Comment != "" {
+		params.Set("comment", f.Comment)
 	}
-	var out apiResponse[[]Zone]
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return "", err
+	if f.Tag != "" {
+		params.Set("tag", f.Tag)
 	}
-	if !out.Success || len(out.Result) == 0 {
-		return "", fmt.Errorf("zone not found: %s", zoneName)
+	if f.PerPage > 0 {
+		params.Set("per_page", strconv.Itoa(f.PerPage))
 	}
-	return out.Result[0].ID, nil
+	return params
 }
 
-// GetARecord fetches a DNS A record by FQDN within a zone.
-func (c *Client) GetARecord(ctx context.Context, zoneID, fqdn string) (*DNSRecord, error) {
-	if zoneID == "" || fqdn == "" {
-		return nil, errors.New("zoneID and fqdn are required")
-	}
+// Ptr returns a pointer to v, for optional fields such as DNSRecord.Priority.
+func Ptr[T any](v T) *T { return &v }
+
+// ListZones returns an iterator over the zones visible to the credentials,
+// optionally filtered by exact zone name.
+func (c *Client) ListZones(ctx context.Context, name string) iter.Seq2[Zone, error] {
 	params := url.Values{}
-	params.Set("type", "A")
-	params.Set("name", fqdn)
-	u := c.buildURL("zones/" + zoneID + "/dns_records?" + params.Encode())
-	req, err := http.NewRequest(http.MethodGet, u, nil)
-	if err != nil {
-		return nil, err
+	if name != "" {
+		params.Set("name", name)
+	}
+	return Paginate[Zone](ctx, c, "zones", params)
+}
+
+// GetZone fetches a zone by id.
+func (c *Client) GetZone(ctx context.Context, zoneID string) (*Zone, error) {
+	if zoneID == "" {
+		return nil, errors.New("zoneID is required")
 	}
-	resp, err := c.do(ctx, req)
+	out, err := request[Zone](ctx, c, http.MethodGet, "zones/"+zoneID, nil)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("get zone failed: %w", err)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("get dns record failed: %s", resp.Status)
+	return &out.Result, nil
+}
+
+// FindZoneID looks up the Zone ID by exact zone name.
+func (c *Client) FindZoneID(ctx context.Context, zoneName string) (string, error) {
+	if zoneName == "" {
+		return "", errors.New("zone name cannot be empty")
 	}
-	var out apiResponse[[]DNSRecord]
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return nil, err
+	for zone, err := range c.ListZones(ctx, zoneName) {
+		if err != nil {
+			return "", fmt.Errorf("zones lookup failed: %w", err)
+		}
+		return zone.ID, nil
 	}
-	if !out.Success || len(out.Result) == 0 {
-		return nil, nil
+	return "", fmt.Errorf("%w: %s", ErrZoneNotFound, zoneName)
+}
+
+// ListDNSRecords returns an iterator over the DNS records of a zone matching filter.
+func (c *Client) ListDNSRecords(ctx context.Context, zoneID string, filter DNSRecordFilter) iter.Seq2[DNSRecord, error] {
+	if zoneID == "" {
+		return func(yield func(DNSRecord, error) bool) {
+			yield(DNSRecord{}, errors.New("zoneID is required"))
+		}
 	}
-	rec := out.Result[0]
-	return &rec, nil
+	return Paginate[DNSRecord](ctx, c, "zones/"+zoneID+"/dns_records", filter.values())
 }
 
-// UpsertARecord creates or updates an A record for NAME within the zone to point to ip.
-// name is the record label (not FQDN). ttl in seconds; proxied per Cloudflare semantics.
-func (c *Client) UpsertARecord(ctx context.Context, zoneID, name, ip string, ttl int, proxied bool) (*DNSRecord, bool, error) {
-	if zoneID == "" || name == "" || ip == "" {
-		return nil, false, errors.New("zoneID, name, and ip are required")
+// GetDNSRecord fetches a DNS record by id.
+func (c *Client) GetDNSRecord(ctx context.Context, zoneID, recordID string) (*DNSRecord, error) {
+	if zoneID == "" || recordID == "" {
+		return nil, errors.New("zoneID and recordID are required")
 	}
-	// Get existing if any by FQDN
-	fqdn := name
-	// The API expects label in create/update; for lookup, caller should use FQDN.
-	// Here we rely on caller to have fetched existing record to determine if update is needed.
-	// For convenience, we will still attempt a lookup by composing later in higher-level logic.
-	_ = fqdn
-	payload := DNSRecord{Type: "A", Name: name, Content: ip, TTL: ttl, Proxied: proxied}
-	rec, err := c.CreateARecord(ctx, zoneID, payload)
-	if err == nil {
-		return rec, true, nil
+	out, err := request[DNSRecord](ctx, c, http.MethodGet, "zones/"+zoneID+"/dns_records/"+recordID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get dns record failed: %w", err)
 	}
-	return nil, false, err
+	return &out.Result, nil
 }
 
-// CreateARecord creates an A record.
-func (c *Client) CreateARecord(ctx context.Context, zoneID string, payload DNSRecord) (*DNSRecord, error) {
-	u := c.buildURL("zones/" + zoneID + "/dns_records")
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
+// CreateDNSRecord validates and creates a DNS record of any supported type.
+func (c *Client) CreateDNSRecord(ctx context.Context, zoneID string, rec DNSRecord) (*DNSRecord, error) {
+	if zoneID == "" {
+		return nil, errors.New("zoneID is required")
 	}
-	req, err := http.NewRequest(http.MethodPost, u, strings.NewReader(string(body)))
-	if err != nil {
+	if err := ValidateDNSRecord(rec); err != nil {
 		return nil, err
 	}
-	resp, err := c.do(ctx, req)
+	out, err := request[DNSRecord](ctx, c, http.MethodPost, "zones/"+zoneID+"/dns_records", rec)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("create dns record failed: %w", err)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("create dns record failed: %s", resp.Status)
+	return &out.Result, nil
+}
+
+// UpdateDNSRecord validates rec and replaces the record with the given id.
+func (c *Client) UpdateDNSRecord(ctx context.Context, zoneID, recordID string, rec DNSRecord) (*DNSRecord, error) {
+	if zoneID == "" || recordID == "" {
+		return nil, errors.New("zoneID and recordID are required")
 	}
-	var out apiResponse[DNSRecord]
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+	if err := ValidateDNSRecord(rec); err != nil {
 		return nil, err
 	}
-	if !out.Success {
-		return nil, errors.New("create dns record unsuccessful")
+	out, err := request[DNSRecord](ctx, c, http.MethodPut, "zones/"+zoneID+"/dns_records/"+recordID, rec)
+	if err != nil {
+		return nil, fmt.Errorf("update dns record failed: %w", err)
 	}
 	return &out.Result, nil
 }
 
-// UpdateARecord updates an existing DNS record by id.
-func (c *Client) UpdateARecord(ctx context.Context, zoneID, recordID string, payload DNSRecord) (*DNSRecord, error) {
+// PatchDNSRecord changes only the non-nil fields of patch on the record with the given id.
+func (c *Client) PatchDNSRecord(ctx context.Context, zoneID, recordID string, patch DNSRecordPatch) (*DNSRecord, error) {
 	if zoneID == "" || recordID == "" {
 		return nil, errors.New("zoneID and recordID are required")
 	}
-	u := c.buildURL("zones/" + zoneID + "/dns_records/" + recordID)
-	body, err := json.Marshal(payload)
+	out, err := request[DNSRecord](ctx, c, http.MethodPatch, "zones/"+zoneID+"/dns_records/"+recordID, patch)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("patch dns record failed: %w", err)
 	}
-	req, err := http.NewRequest(http.MethodPut, u, strings.NewReader(string(body)))
-	if err != nil {
-		return nil, err
+	return &out.Result, nil
+}
+
+// DeleteDNSRecord deletes the record with the given id.
+func (c *Client) DeleteDNSRecord(ctx context.Context, zoneID, recordID string) error {
+	if zoneID == "" || recordID == "" {
+		return errors.New("zoneID and recordID are required")
 	}
-	resp, err := c.do(ctx, req)
-	if err != nil {
-		return nil, err
+	if _, err := request[struct {
+		ID string `json:"id"`
+	}](ctx, c, http.MethodDelete, "zones/"+zoneID+"/dns_records/"+recordID, nil); err != nil {
+		return fmt.Errorf("delete dns record failed: %w", err)
+	}
+	return nil
+}
+
+// GetARecord fetches a DNS A record by FQDN within a zone.
+func (c *Client) GetARecord(ctx context.Context, zoneID, fqdn string) (*DNSRecord, error) {
+	if zoneID == "" || fqdn == "" {
+		return nil, errors.New("zoneID and fqdn are required")
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("update dns record failed: %s", resp.Status)
+	for rec, err := range c.ListDNSRecords(ctx, zoneID, DNSRecordFilter{Type: RecordTypeA, Name: fqdn}) {
+		if err != nil {
+			return nil, fmt.Errorf("get dns record failed: %w", err)
+		}
+		return &rec, nil
 	}
-	var out apiResponse[DNSRecord]
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return nil, err
+	return nil, nil
+}
+
+// UpsertARecord creates or updates an A record for NAME within the zone to point to ip.
+// name may be a label or FQDN. ttl in seconds; proxied per Cloudflare semantics.
+// The returned bool reports whether the record was created or updated.
+func (c *Client) UpsertARecord(ctx context.Context, zoneID, name, ip string, ttl int, proxied bool) (*DNSRecord, bool, error) {
+	if zoneID == "" || name == "" || ip == "" {
+		return nil, false, errors.New("zoneID, name, and ip are required")
 	}
-	if !out.Success {
-		return nil, errors.New("update dns record unsuccessful")
+	payload := DNSRecord{Type: RecordTypeA, Name: name, Content: ip, TTL: ttl, Proxied: proxied}
+	res, err := c.UpsertDNSRecord(ctx, zoneID, payload, UpsertOptions{})
+	if err != nil {
+		return nil, false, err
 	}
-	return &out.Result, nil
+	return res.Record, res.Action != UpsertUnchanged, nil
+}
+
+// CreateARecord creates an A record. It is a convenience wrapper around
+// CreateDNSRecord that defaults the record type to A.
+func (c *Client) CreateARecord(ctx context.Context, zoneID string, payload DNSRecord) (*DNSRecord, error) {
+	if payload.Type == "" {
+		payload.Type = RecordTypeA
+	}
+	return c.CreateDNSRecord(ctx, zoneID, payload)
+}
+
+// UpdateARecord updates an existing DNS record by id. It is a convenience
+// wrapper around UpdateDNSRecord that defaults the record type to A.
+func (c *Client) UpdateARecord(ctx context.Context, zoneID, recordID string, payload DNSRecord) (*DNSRecord, error) {
+	if payload.Type == "" {
+		payload.Type = RecordTypeA
+	}
+	return c.UpdateDNSRecord(ctx, zoneID, recordID, payload)
 }
@@ -0,0 +1,150 @@
+package cloudflare_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListDNSRecords_Filter(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/zones/zid/dns_records", r.URL.Path)
+		q := r.URL.Query()
+		require.Equal(t, "TXT", q.Get("type"))
+		require.Equal(t, "_acme.example.com", q.Get("name"))
+		require.Equal(t, "false", q.Get("proxied"))
+		require.Equal(t, "500", q.Get("per_page"))
+		json.NewEncoder(w).Encode(map[string]any{
+			"success": true,
+			"result": []map[string]any{
+				{"id": "r1", "type": "TXT", "name": "_acme.example.com", "content": "\"a\"", "ttl": 120},
+				{"id": "r2", "type": "TXT", "name": "_acme.example.com", "content": "\"b\"", "ttl": 120},
+			},
+			"result_info": map[string]any{"page": 1, "per_page": 500, "count": 2, "total_count": 2, "total_pages": 1},
+		})
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	recs, err := cloudflare.Collect(c.ListDNSRecords(context.Background(), "zid", cloudflare.DNSRecordFilter{
+		Type: "txt", Name: "_acme.example.com", Proxied: cloudflare.Ptr(false), PerPage: 500,
+	}))
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+	require.Equal(t, "r2", recs[1].ID)
+}
+
+func TestDNSRecordCRUD(t *testing.T) {
+	var got []recorded
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		rec := capture(r)
+		got = append(got, rec)
+		result := map[string]any{"id": "rid"}
+		if rec.Body != "" {
+			_ = json.Unmarshal([]byte(rec.Body), &result)
+			result["id"] = "rid"
+		}
+		json.NewEncoder(w).Encode(map[string]any{"success": true, "result": result})
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	ctx := context.Background()
+
+	srvRec := cloudflare.DNSRecord{
+		Type: cloudflare.RecordTypeSRV,
+		Name: "_sip._tcp.example.com",
+		TTL:  300,
+		Data: map[string]any{"priority": 10, "weight": 5, "port": 5060, "target": "sip.example.com"},
+	}
+	created, err := c.CreateDNSRecord(ctx, "zid", srvRec)
+	require.NoError(t, err)
+	require.Equal(t, "rid", created.ID)
+	require.Equal(t, "sip.example.com", created.Data["target"])
+
+	mx := cloudflare.DNSRecord{Type: "MX", Name: "example.com", Content: "mail.example.com", TTL: 1, Priority: cloudflare.Ptr[uint16](0)}
+	_, err = c.UpdateDNSRecord(ctx, "zid", "rid", mx)
+	require.NoError(t, err)
+
+	rec, err := c.PatchDNSRecord(ctx, "zid", "rid", cloudflare.DNSRecordPatch{TTL: cloudflare.Ptr(600), Comment: cloudflare.Ptr("")})
+	require.NoError(t, err)
+	require.Equal(t, 600, rec.TTL)
+
+	_, err = c.GetDNSRecord(ctx, "zid", "rid")
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteDNSRecord(ctx, "zid", "rid"))
+
+	require.Len(t, got, 5)
+	require.Equal(t, http.MethodPost, got[0].Method)
+	require.Equal(t, "/zones/zid/dns_records", got[0].Path)
+	require.Contains(t, got[0].Body, `"data":{`)
+	require.NotContains(t, got[0].Body, `"content"`)
+
+	require.Equal(t, http.MethodPut, got[1].Method)
+	require.Contains(t, got[1].Body, `"priority":0`)
+
+	require.Equal(t, http.MethodPatch, got[2].Method)
+	require.JSONEq(t, `{"ttl":600,"comment":""}`, got[2].Body)
+
+	require.Equal(t, http.MethodGet, got[3].Method)
+	require.Equal(t, "/zones/zid/dns_records/rid", got[3].Path)
+	require.Equal(t, http.MethodDelete, got[4].Method)
+	require.Equal(t, "/zones/zid/dns_records/rid", got[4].Path)
+}
+
+func TestCreateDNSRecord_ValidationFailsWithoutRequest(t *testing.T) {
+	srv := newTestServer(t, func(_ http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		t.Fatalf("unexpected request: %s %s", r.Method, b)
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	_, err := c.CreateDNSRecord(context.Background(), "zid", cloudflare.DNSRecord{Type: "AAAA", Name: "home", Content: "203.0.113.1"})
+	require.ErrorContains(t, err, "not an IPv6 address")
+}
+
+func TestValidateDNSRecord(t *testing.T) {
+	cases := []struct {
+		name    string
+		rec     cloudflare.DNSRecord
+		wantErr string
+	}{
+		{name: "a ok", rec: cloudflare.DNSRecord{Type: "A", Name: "home", Content: "203.0.113.1", TTL: 1, Proxied: true}},
+		{name: "a invalid ip", rec: cloudflare.DNSRecord{Type: "A", Name: "home", Content: "2001:db8::1"}, wantErr: "not an IPv4 address"},
+		{name: "aaaa ok", rec: cloudflare.DNSRecord{Type: "AAAA", Name: "home", Content: "2001:db8::1", TTL: 300}},
+		{name: "cname ok", rec: cloudflare.DNSRecord{Type: "CNAME", Name: "www", Content: "example.com"}},
+		{name: "cname bad host", rec: cloudflare.DNSRecord{Type: "CNAME", Name: "www", Content: "a..b"}, wantErr: "not a valid hostname"},
+		{name: "txt ok", rec: cloudflare.DNSRecord{Type: "TXT", Name: "x", Content: "v=spf1 -all"}},
+		{name: "txt proxied", rec: cloudflare.DNSRecord{Type: "TXT", Name: "x", Content: "v", Proxied: true}, wantErr: "cannot be proxied"},
+		{name: "mx missing priority", rec: cloudflare.DNSRecord{Type: "MX", Name: "example.com", Content: "mx.example.com"}, wantErr: "priority is required"},
+		{name: "caa missing data key", rec: cloudflare.DNSRecord{Type: "CAA", Name: "example.com", Data: map[string]any{"flags": 0, "tag": "issue"}}, wantErr: "data.value is required"},
+		{name: "caa content", rec: cloudflare.DNSRecord{Type: "CAA", Name: "example.com", Content: `0 issue "letsencrypt.org"`}},
+		{name: "srv empty", rec: cloudflare.DNSRecord{Type: "SRV", Name: "_sip._tcp"}, wantErr: "content or data is required"},
+		{name: "uri requires data", rec: cloudflare.DNSRecord{Type: "URI", Name: "_http._tcp", Content: "x", Priority: cloudflare.Ptr[uint16](1)}, wantErr: "data is required"},
+		{name: "loc ok", rec: cloudflare.DNSRecord{Type: "LOC", Name: "here", Data: map[string]any{
+			"lat_degrees": 52, "lat_minutes": 22, "lat_seconds": 23, "lat_direction": "N",
+			"long_degrees": 4, "long_minutes": 53, "long_seconds": 32, "long_direction": "E",
+			"altitude": 0, "size": 1, "precision_horz": 10000, "precision_vert": 10,
+		}}},
+		{name: "data on simple type", rec: cloudflare.DNSRecord{Type: "A", Name: "x", Data: map[string]any{"a": 1}}, wantErr: "data is not supported"},
+		{name: "bad ttl", rec: cloudflare.DNSRecord{Type: "A", Name: "x", Content: "203.0.113.1", TTL: 5}, wantErr: "ttl must be"},
+		{name: "unknown type", rec: cloudflare.DNSRecord{Type: "SPF", Name: "x", Content: "v"}, wantErr: "unsupported record type"},
+		{name: "missing name", rec: cloudflare.DNSRecord{Type: "A", Content: "203.0.113.1"}, wantErr: "name is required"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := cloudflare.ValidateDNSRecord(tc.rec)
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package cloudflare_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+// bufferLogger returns a debug-level text logger writing to the buffer.
+func bufferLogger() (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})), &buf
+}
+
+func TestDryRun_MutationsAreNotSent(t *testing.T) {
+	logger, logs := bufferLogger()
+	existing := cloudflare.DNSRecord{Type: "A", Name: "www.example.com", Content: "203.0.113.1", TTL: 300, Comment: "keep"}
+	f, srv := newFakeDNS(t, "zid", "example.com", existing)
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL), cloudflare.WithDryRun(), cloudflare.WithLogger(logger))
+	ctx := context.Background()
+
+	created, err := c.CreateDNSRecord(ctx, "zid", cloudflare.DNSRecord{Type: "AAAA", Name: "www.example.com", Content: "2001:db8::1", TTL: 1})
+	require.NoError(t, err)
+	require.Equal(t, cloudflare.DryRunID, created.ID)
+	require.Equal(t, "2001:db8::1", created.Content)
+
+	updated, err := c.UpdateDNSRecord(ctx, "zid", "rec1", cloudflare.DNSRecord{Type: "A", Name: "www.example.com", Content: "203.0.113.2", TTL: 60})
+	require.NoError(t, err)
+	require.Equal(t, "rec1", updated.ID)
+	require.Equal(t, 60, updated.TTL)
+
+	patched, err := c.PatchDNSRecord(ctx, "zid", "rec1", cloudflare.DNSRecordPatch{Content: cloudflare.Ptr("203.0.113.3")})
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.3", patched.Content)
+	require.Equal(t, "keep", patched.Comment, "unpatched fields come from the current record")
+	require.Equal(t, 300, patched.TTL)
+
+	require.NoError(t, c.DeleteDNSRecord(ctx, "zid", "rec1"))
+
+	res, err := c.ImportZoneFile(ctx, "zid", strings.NewReader("www 300 IN A 203.0.113.1\n"), false)
+	require.NoError(t, err)
+	require.Zero(t, res.RecordsAdded)
+
+	require.Empty(t, f.writes)
+	require.Len(t, f.records, 1)
+	out := logs.String()
+	require.Contains(t, out, `level=INFO msg="dry run: request not sent" method=POST path=/zones/zid/dns_records body="{\"type\":\"AAAA\"`)
+	require.Contains(t, out, "method=PUT path=/zones/zid/dns_records/rec1 body=")
+	require.Contains(t, out, `method=PATCH path=/zones/zid/dns_records/rec1 body="{\"content\":\"203.0.113.3\"}"`)
+	require.Contains(t, out, "method=DELETE path=/zones/zid/dns_records/rec1\n")
+	require.Contains(t, out, "method=POST path=/zones/zid/dns_records/import body_bytes=")
+}
+
+func TestDryRun_UpsertAndReconcileComputeRealChanges(t *testing.T) {
+	f, srv := newFakeDNS(t, "zid", "example.com",
+		cloudflare.DNSRecord{Type: "A", Name: "home.example.com", Content: "203.0.113.1", TTL: 1},
+	)
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL), cloudflare.WithDryRun(),
+		cloudflare.WithLogger(slog.New(slog.DiscardHandler)))
+	ctx := context.Background()
+
+	res, err := c.UpsertDNSRecord(ctx, "zid", cloudflare.DNSRecord{Type: "A", Name: "home", Content: "203.0.113.9", TTL: 1}, cloudflare.UpsertOptions{ZoneName: "example.com"})
+	require.NoError(t, err)
+	require.Equal(t, cloudflare.UpsertUpdated, res.Action)
+	require.Equal(t, "203.0.113.1", res.Previous.Content)
+	require.Equal(t, "203.0.113.9", res.Record.Content)
+
+	plan, err := c.Reconcile(ctx, "zid", []cloudflare.DNSRecord{{Type: "TXT", Name: "@", Content: "hello", TTL: 1}},
+		cloudflare.ReconcileOptions{ZoneName: "example.com", ManageAll: true})
+	require.NoError(t, err)
+	require.Equal(t, 1, plan.Counts()[cloudflare.ChangeCreate])
+	require.Equal(t, 1, plan.Counts()[cloudflare.ChangeDelete])
+
+	require.Empty(t, f.writes)
+}
+
+func TestDryRun_CanceledContext(t *testing.T) {
+	_, srv := newFakeDNS(t, "zid", "example.com")
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL), cloudflare.WithDryRun())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := c.DeleteDNSRecord(ctx, "zid", "rec1")
+	require.ErrorIs(t, err, context.Canceled)
+}
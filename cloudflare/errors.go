@@ -0,0 +1,143 @@
+package cloudflare
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Header carrying the Cloudflare Ray ID used to correlate requests in support tickets.
+const headerCFRay = "CF-Ray"
+
+// Cloudflare API error codes used by the IsX helpers.
+const (
+	codeAuthError          = 10000
+	codeUnknownAuthKey     = 9103
+	codeInvalidAuthToken   = 9106
+	codeUnauthorizedToken  = 9109
+	codeInvalidObjectID    = 7003
+	codeRecordNotFound     = 81044
+	codeRecordConflict     = 81053
+	codeRecordExists       = 81057
+	codeIdenticalRecord    = 81058
+	codeRateLimitExceeded  = 971
+	codeRateLimitExceeded2 = 10429
+)
+
+// ErrZoneNotFound is returned when a zone lookup by name yields no results.
+var ErrZoneNotFound = errors.New("zone not found")
+
+// APIMessage is an entry of the errors or messages array in a Cloudflare response.
+type APIMessage struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// APIError describes a failed Cloudflare API call: either a non-2xx HTTP status
+// or a response envelope with success=false.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Errors holds the error codes and messages reported by Cloudflare.
+	Errors []APIMessage
+	// Messages holds informational messages reported by Cloudflare.
+	Messages []APIMessage
+	// RayID is the value of the CF-Ray response header, if present.
+	RayID string
+	// Method and Path identify the request that failed.
+	Method string
+	Path   string
+}
+
+func newAPIError(req *http.Request, resp *http.Response, errs, msgs []APIMessage) *APIError {
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Errors:     errs,
+		Messages:   msgs,
+		RayID:      resp.Header.Get(headerCFRay),
+		Method:     req.Method,
+		Path:       req.URL.Path,
+	}
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s: %d %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+	for i, m := range e.Errors {
+		if i == 0 {
+			b.WriteString(":")
+		} else {
+			b.WriteString(";")
+		}
+		fmt.Fprintf(&b, " %s (%d)", m.Message, m.Code)
+	}
+	if e.RayID != "" {
+		fmt.Fprintf(&b, " [ray %s]", e.RayID)
+	}
+	return b.String()
+}
+
+// HasCode reports whether Cloudflare returned the given error code.
+func (e *APIError) HasCode(code int) bool {
+	for _, m := range e.Errors {
+		if m.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *APIError) hasAnyCode(codes ...int) bool {
+	for _, code := range codes {
+		if e.HasCode(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotFound reports whether err indicates a missing zone or resource.
+func IsNotFound(err error) bool {
+	if errors.Is(err, ErrZoneNotFound) {
+		return true
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusNotFound || apiErr.hasAnyCode(codeInvalidObjectID, codeRecordNotFound)
+}
+
+// IsAuth reports whether err is an authentication or authorization failure.
+func IsAuth(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return true
+	}
+	return apiErr.hasAnyCode(codeAuthError, codeUnknownAuthKey, codeInvalidAuthToken, codeUnauthorizedToken)
+}
+
+// IsRateLimited reports whether err was caused by exceeding the API rate limit.
+func IsRateLimited(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.hasAnyCode(codeRateLimitExceeded, codeRateLimitExceeded2)
+}
+
+// IsConflict reports whether err indicates the resource already exists or
+// conflicts with an existing one (e.g. code 81057, record already exists).
+func IsConflict(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusConflict || apiErr.hasAnyCode(codeRecordConflict, codeRecordExists, codeIdenticalRecord)
+}
@@ -0,0 +1,96 @@
+package cloudflare_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIError_ParsesEnvelopeAndRayID(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("CF-Ray", "8a1b2c3d4e5f6789-AMS")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"errors":  []map[string]any{{"code": 10000, "message": "Authentication error"}},
+			"result":  nil,
+		})
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	_, err := c.FindZoneID(context.Background(), "example.com")
+	require.Error(t, err)
+
+	var apiErr *cloudflare.APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	require.Equal(t, "8a1b2c3d4e5f6789-AMS", apiErr.RayID)
+	require.Equal(t, http.MethodGet, apiErr.Method)
+	require.Equal(t, "/zones", apiErr.Path)
+	require.True(t, apiErr.HasCode(10000))
+	require.Contains(t, err.Error(), "Authentication error (10000)")
+	require.Contains(t, err.Error(), "8a1b2c3d4e5f6789-AMS")
+	require.True(t, cloudflare.IsAuth(err))
+	require.False(t, cloudflare.IsNotFound(err))
+}
+
+func TestAPIError_UnsuccessfulEnvelopeWith2xx(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"errors":  []map[string]any{{"code": 81057, "message": "Record already exists."}},
+		})
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	_, err := c.CreateARecord(context.Background(), "zid", cloudflare.DNSRecord{Type: "A", Name: "home", Content: "203.0.113.1", TTL: 1})
+	require.Error(t, err)
+	require.True(t, cloudflare.IsConflict(err))
+	require.False(t, cloudflare.IsAuth(err))
+}
+
+func TestAPIError_Helpers(t *testing.T) {
+	cases := []struct {
+		name    string
+		status  int
+		code    int
+		checker func(error) bool
+	}{
+		{name: "not found status", status: http.StatusNotFound, checker: cloudflare.IsNotFound},
+		{name: "invalid object id", status: http.StatusBadRequest, code: 7003, checker: cloudflare.IsNotFound},
+		{name: "unauthorized", status: http.StatusUnauthorized, checker: cloudflare.IsAuth},
+		{name: "rate limited status", status: http.StatusTooManyRequests, checker: cloudflare.IsRateLimited},
+		{name: "rate limited code", status: http.StatusBadRequest, code: 971, checker: cloudflare.IsRateLimited},
+		{name: "conflict status", status: http.StatusConflict, checker: cloudflare.IsConflict},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := &cloudflare.APIError{StatusCode: tc.status}
+			if tc.code != 0 {
+				err.Errors = []cloudflare.APIMessage{{Code: tc.code}}
+			}
+			require.True(t, tc.checker(err))
+			require.False(t, tc.checker(errors.New("plain")))
+		})
+	}
+}
+
+func TestFindZoneID_NotFoundIsNotFound(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"success": true, "result": []any{}})
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	_, err := c.FindZoneID(context.Background(), "missing.com")
+	require.True(t, cloudflare.IsNotFound(err))
+	require.Equal(t, "zone not found: missing.com", err.Error())
+}
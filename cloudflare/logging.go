@@ -0,0 +1,71 @@
+package cloudflare
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// WithLogger logs every request attempt and its response at debug level
+// (method, URL, status, duration, Ray ID and headers, with the Authorization
+// and X-Auth-Key values redacted), retries and upsert decisions at debug, and
+// dry-run simulations at info. Without it the client only logs dry runs, to
+// slog.Default().
+func WithLogger(l *slog.Logger) Option { return func(o *Options) { o.Logger = l } }
+
+// logMiddleware is the innermost middleware of clients with a logger, so it
+// logs requests exactly as sent.
+func logMiddleware(l *slog.Logger) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			if !l.Enabled(ctx, slog.LevelDebug) {
+				return next(req)
+			}
+			l.DebugContext(ctx, "api request",
+				"method", req.Method, "url", req.URL.String(), headerGroup(req.Header))
+			start := time.Now()
+			resp, err := next(req)
+			if err != nil {
+				l.DebugContext(ctx, "api request failed",
+					"method", req.Method, "url", req.URL.String(), "duration", time.Since(start), "error", err)
+				return resp, err
+			}
+			l.DebugContext(ctx, "api response",
+				"method", req.Method, "url", req.URL.String(), "status", resp.StatusCode,
+				"duration", time.Since(start), "ray_id", resp.Header.Get(headerCFRay), headerGroup(resp.Header))
+			return resp, err
+		}
+	}
+}
+
+// headerGroup returns the headers as a "headers" group, secrets redacted.
+func headerGroup(h http.Header) slog.Attr {
+	h = h.Clone()
+	redactHeaders(h)
+	attrs := make([]any, 0, len(h))
+	for k, v := range h {
+		if len(v) == 1 {
+			attrs = append(attrs, slog.String(k, v[0]))
+		} else {
+			attrs = append(attrs, slog.Any(k, v))
+		}
+	}
+	return slog.Group("headers", attrs...)
+}
+
+// debug logs at debug level if the client has a logger.
+func (c *Client) debug(ctx context.Context, msg string, args ...any) {
+	if c.logger != nil {
+		c.logger.DebugContext(ctx, msg, args...)
+	}
+}
+
+// dryRunLogger is the logger dry-run simulations are reported to.
+func (c *Client) dryRunLogger() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return slog.Default()
+}
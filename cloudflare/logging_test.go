@@ -0,0 +1,67 @@
+package cloudflare_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLogger_RequestsAtDebugWithSecretsRedacted(t *testing.T) {
+	var calls atomic.Int32
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("CF-Ray", "8a1b2c3d4e5f-AMS")
+		zoneOK(w)
+	})
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := mustClient(t, cloudflare.WithGlobalKey("me@example.com", "key-value"), cloudflare.WithBaseURL(srv.URL),
+		cloudflare.WithRetryPolicy(fastRetry()), cloudflare.WithLogger(logger))
+	_, err := c.FindZoneID(context.Background(), "example.com")
+	require.NoError(t, err)
+	require.NotContains(t, buf.String(), "key-value")
+
+	var msgs []string
+	var last map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var entry map[string]any
+		require.NoError(t, dec.Decode(&entry))
+		msgs = append(msgs, entry["msg"].(string))
+		require.Equal(t, "DEBUG", entry["level"])
+		if entry["msg"] == "api request" {
+			headers := entry["headers"].(map[string]any)
+			require.Equal(t, "[REDACTED]", headers["X-Auth-Key"])
+			require.Equal(t, "me@example.com", headers["X-Auth-Email"])
+		}
+		last = entry
+	}
+	require.Equal(t, []string{"api request", "api response", "retrying api request", "api request", "api response"}, msgs)
+	require.Equal(t, float64(200), last["status"])
+	require.Equal(t, "8a1b2c3d4e5f-AMS", last["ray_id"])
+	require.Equal(t, http.MethodGet, last["method"])
+}
+
+func TestWithLogger_InfoLevelIsQuiet(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) { zoneOK(w) })
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL),
+		cloudflare.WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	_, err := c.FindZoneID(context.Background(), "example.com")
+	require.NoError(t, err)
+	require.Empty(t, buf.String())
+}
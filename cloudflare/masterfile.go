@@ -0,0 +1,510 @@
+package cloudflare
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Cloudflare annotates proxied records in exported zone files with this tag.
+const cfProxiedTag = "cf-proxied:true"
+
+// maxTXTChunk is the longest character-string allowed in TXT rdata.
+const maxTXTChunk = 255
+
+// zoneToken is a single field of a master file entry.
+type zoneToken struct {
+	text   string
+	quoted bool
+}
+
+// zoneEntry is one logical master file entry; parentheses may span lines.
+type zoneEntry struct {
+	line       int
+	blankOwner bool
+	tokens     []zoneToken
+	comment    string
+}
+
+// ParseZoneFile parses an RFC 1035 master file into DNS records. origin is the
+// initial $ORIGIN (usually the zone apex). Relative names and "@" are expanded
+// against the current origin and returned as FQDNs without trailing dot. The
+// $ORIGIN and $TTL directives, omitted owners, optional TTL and class fields
+// and parenthesized multi-line entries are supported; SOA records are skipped
+// because Cloudflare manages them. Entries annotated with Cloudflare's
+// "cf-proxied:true" tag are marked proxied. Records without a TTL use $TTL,
+// else the previous explicit TTL, else 1 (automatic).
+func ParseZoneFile(r io.Reader, origin string) ([]DNSRecord, error) {
+	entries, err := scanZoneEntries(r)
+	if err != nil {
+		return nil, err
+	}
+	origin = strings.TrimSuffix(origin, ".")
+	var (
+		records    []DNSRecord
+		defaultTTL = -1
+		lastTTL    = ttlAuto
+		lastOwner  string
+	)
+	for _, e := range entries {
+		t := e.tokens
+		if !t[0].quoted && strings.HasPrefix(t[0].text, "$") {
+			directive := strings.ToUpper(t[0].text)
+			if len(t) < 2 {
+				return nil, fmt.Errorf("line %d: %s requires an argument", e.line, directive)
+			}
+			switch directive {
+			case "$ORIGIN":
+				origin = expandName(t[1].text, origin)
+			case "$TTL":
+				ttl, ok := parseZoneTTL(t[1].text)
+				if !ok {
+					return nil, fmt.Errorf("line %d: invalid $TTL %q", e.line, t[1].text)
+				}
+				defaultTTL = ttl
+			default:
+				return nil, fmt.Errorf("line %d: unsupported directive %s", e.line, directive)
+			}
+			continue
+		}
+
+		owner := lastOwner
+		if !e.blankOwner {
+			owner, t = expandName(t[0].text, origin), t[1:]
+		}
+		if owner == "" {
+			return nil, fmt.Errorf("line %d: missing owner name", e.line)
+		}
+		lastOwner = owner
+
+		ttl := -1
+		for len(t) > 0 && !t[0].quoted {
+			if strings.EqualFold(t[0].text, "IN") {
+				t = t[1:]
+				continue
+			}
+			if v, ok := parseZoneTTL(t[0].text); ok && ttl < 0 {
+				ttl, t = v, t[1:]
+				continue
+			}
+			break
+		}
+		if len(t) == 0 {
+			return nil, fmt.Errorf("line %d: missing record type", e.line)
+		}
+		switch {
+		case ttl >= 0:
+			lastTTL = ttl
+		case defaultTTL >= 0:
+			ttl = defaultTTL
+		default:
+			ttl = lastTTL
+		}
+
+		typ := strings.ToUpper(t[0].text)
+		if typ == "SOA" {
+			continue
+		}
+		rec, err := zoneRecord(owner, typ, t[1:], origin)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", e.line, err)
+		}
+		rec.TTL = ttl
+		rec.Proxied = strings.Contains(e.comment, cfProxiedTag)
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// zoneRecord converts the rdata fields of a master file entry into the
+// Cloudflare representation of the record.
+func zoneRecord(owner, typ string, rdata []zoneToken, origin string) (DNSRecord, error) {
+	rec := DNSRecord{Type: typ, Name: owner}
+	want := func(n int) error {
+		if len(rdata) != n {
+			return fmt.Errorf("%s record expects %d rdata fields, got %d", typ, n, len(rdata))
+		}
+		return nil
+	}
+	switch typ {
+	case RecordTypeA, RecordTypeAAAA:
+		if err := want(1); err != nil {
+			return rec, err
+		}
+		rec.Content = rdata[0].text
+	case RecordTypeCNAME, RecordTypeNS, RecordTypePTR:
+		if err := want(1); err != nil {
+			return rec, err
+		}
+		rec.Content = expandName(rdata[0].text, origin)
+	case RecordTypeMX:
+		if err := want(2); err != nil {
+			return rec, err
+		}
+		pref, err := parseUint16(rdata[0].text)
+		if err != nil {
+			return rec, err
+		}
+		rec.Priority, rec.Content = &pref, expandName(rdata[1].text, origin)
+	case RecordTypeTXT:
+		if len(rdata) == 0 {
+			return rec, errors.New("TXT record requires rdata")
+		}
+		if len(rdata) == 1 {
+			rec.Content = rdata[0].text
+		} else {
+			rec.Content = joinZoneTokens(rdata, true)
+		}
+	case RecordTypeSRV:
+		if err := want(4); err != nil {
+			return rec, err
+		}
+		nums, err := parseUint16s(rdata[:3])
+		if err != nil {
+			return rec, err
+		}
+		rec.Data = map[string]any{"priority": nums[0], "weight": nums[1], "port": nums[2], "target": expandName(rdata[3].text, origin)}
+	case RecordTypeCAA:
+		if err := want(3); err != nil {
+			return rec, err
+		}
+		flags, err := strconv.ParseUint(rdata[0].text, 10, 8)
+		if err != nil {
+			return rec, fmt.Errorf("invalid CAA flags %q", rdata[0].text)
+		}
+		rec.Data = map[string]any{"flags": int(flags), "tag": rdata[1].text, "value": rdata[2].text}
+	case RecordTypeURI:
+		if err := want(3); err != nil {
+			return rec, err
+		}
+		nums, err := parseUint16s(rdata[:2])
+		if err != nil {
+			return rec, err
+		}
+		rec.Priority = Ptr(uint16(nums[0]))
+		rec.Data = map[string]any{"weight": nums[1], "target": rdata[2].text}
+	case RecordTypeHTTPS, RecordTypeSVCB, RecordTypeTLSA, RecordTypeSSHFP, RecordTypeNAPTR,
+		RecordTypeDS, RecordTypeDNSKEY, RecordTypeLOC:
+		if len(rdata) == 0 {
+			return rec, fmt.Errorf("%s record requires rdata", typ)
+		}
+		rec.Content = joinZoneTokens(rdata, false)
+	default:
+		return rec, fmt.Errorf("unsupported record type %s", typ)
+	}
+	return rec, nil
+}
+
+// WriteZoneFile serializes records as an RFC 1035 master file with the given
+// origin. Proxied records carry Cloudflare's cf-proxied tag in a comment so
+// the output round-trips through ParseZoneFile and Cloudflare's importer.
+func WriteZoneFile(w io.Writer, origin string, records []DNSRecord) error {
+	bw := bufio.NewWriter(w)
+	if origin != "" {
+		fmt.Fprintf(bw, "$ORIGIN %s\n", absoluteName(origin))
+	}
+	for _, r := range records {
+		rdata, err := zoneRData(r)
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", r.Type, r.Name, err)
+		}
+		fmt.Fprintf(bw, "%s\t%d\tIN\t%s\t%s", absoluteName(r.Name), normalizeTTL(r.TTL), strings.ToUpper(r.Type), rdata)
+		if r.Proxied {
+			fmt.Fprintf(bw, " ; cf_tags=%s", cfProxiedTag)
+		}
+		bw.WriteString("\n")
+	}
+	return bw.Flush()
+}
+
+// zoneRData renders the rdata of r in master file syntax.
+func zoneRData(r DNSRecord) (string, error) {
+	typ := strings.ToUpper(r.Type)
+	d := r.Data
+	switch typ {
+	case RecordTypeCNAME, RecordTypeNS, RecordTypePTR:
+		return absoluteName(r.Content), nil
+	case RecordTypeMX:
+		if r.Priority == nil {
+			return "", errors.New("priority is required")
+		}
+		return fmt.Sprintf("%d %s", *r.Priority, absoluteName(r.Content)), nil
+	case RecordTypeTXT:
+		if strings.HasPrefix(r.Content, `"`) {
+			return r.Content, nil
+		}
+		return quoteTXT(r.Content), nil
+	case RecordTypeSRV:
+		if d != nil {
+			return fmt.Sprintf("%s %s %s %s", dataString(d["priority"]), dataString(d["weight"]),
+				dataString(d["port"]), absoluteName(dataString(d["target"]))), nil
+		}
+	case RecordTypeCAA:
+		if d != nil {
+			return fmt.Sprintf("%s %s %s", dataString(d["flags"]), dataString(d["tag"]), quoteZoneString(dataString(d["value"]))), nil
+		}
+	case RecordTypeURI:
+		if r.Priority == nil || d == nil {
+			return "", errors.New("priority and data are required")
+		}
+		return fmt.Sprintf("%d %s %s", *r.Priority, dataString(d["weight"]), quoteZoneString(dataString(d["target"]))), nil
+	}
+	if r.Content == "" {
+		return "", errors.New("content is required")
+	}
+	if r.Priority != nil && typ == RecordTypeSRV {
+		return fmt.Sprintf("%d %s", *r.Priority, r.Content), nil
+	}
+	return r.Content, nil
+}
+
+// scanZoneEntries splits master file text into logical entries, joining
+// parenthesized continuations and collecting comments.
+func scanZoneEntries(r io.Reader) ([]zoneEntry, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	var (
+		entries []zoneEntry
+		cur     *zoneEntry
+		depth   int
+		lineNo  int
+	)
+	for sc.Scan() {
+		lineNo++
+		line := sc.Text()
+		if cur == nil {
+			cur = &zoneEntry{line: lineNo, blankOwner: line != "" && (line[0] == ' ' || line[0] == '\t')}
+		}
+		var err error
+		depth, err = tokenizeZoneLine(line, depth, cur)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if depth == 0 {
+			if len(cur.tokens) > 0 {
+				entries = append(entries, *cur)
+			}
+			cur = nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("line %d: unbalanced parentheses", cur.line)
+	}
+	return entries, nil
+}
+
+// tokenizeZoneLine appends the tokens of line to e and returns the updated
+// parenthesis depth.
+func tokenizeZoneLine(line string, depth int, e *zoneEntry) (int, error) {
+	var (
+		b       strings.Builder
+		inWord  bool
+		inQuote bool
+	)
+	flush := func() {
+		if inWord {
+			e.tokens = append(e.tokens, zoneToken{text: b.String()})
+			b.Reset()
+			inWord = false
+		}
+	}
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		if inQuote {
+			switch ch {
+			case '\\':
+				if i+3 < len(line) && isDigits(line[i+1:i+4]) {
+					n, _ := strconv.Atoi(line[i+1 : i+4])
+					if n > 255 {
+						return depth, fmt.Errorf("invalid escape \\%s: exceeds 255", line[i+1:i+4])
+					}
+					b.WriteByte(byte(n))
+					i += 3
+				} else if i+1 < len(line) {
+					i++
+					b.WriteByte(line[i])
+				}
+			case '"':
+				e.tokens = append(e.tokens, zoneToken{text: b.String(), quoted: true})
+				b.Reset()
+				inQuote = false
+			default:
+				b.WriteByte(ch)
+			}
+			continue
+		}
+		switch {
+		case ch == ';':
+			flush()
+			e.comment += line[i+1:]
+			return depth, nil
+		case ch == '"':
+			flush()
+			inQuote = true
+		case ch == '(':
+			flush()
+			depth++
+		case ch == ')':
+			flush()
+			if depth == 0 {
+				return depth, errors.New("unexpected ')'")
+			}
+			depth--
+		case unicode.IsSpace(rune(ch)):
+			flush()
+		case ch == '\\' && i+1 < len(line):
+			i++
+			b.WriteByte(line[i])
+			inWord = true
+		default:
+			b.WriteByte(ch)
+			inWord = true
+		}
+	}
+	if inQuote {
+		return depth, errors.New("unterminated quoted string")
+	}
+	flush()
+	return depth, nil
+}
+
+// parseZoneTTL parses a TTL in seconds or BIND units such as "1h30m".
+func parseZoneTTL(s string) (int, bool) {
+	if s == "" {
+		return 0, false
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, n >= 0
+	}
+	total, num := 0, -1
+	for _, r := range strings.ToLower(s) {
+		if r >= '0' && r <= '9' {
+			num = max(num, 0)*10 + int(r-'0')
+			continue
+		}
+		if num < 0 {
+			return 0, false
+		}
+		switch r {
+		case 's':
+		case 'm':
+			num *= 60
+		case 'h':
+			num *= 3600
+		case 'd':
+			num *= 86400
+		case 'w':
+			num *= 7 * 86400
+		default:
+			return 0, false
+		}
+		total, num = total+num, -1
+	}
+	if num >= 0 {
+		return 0, false
+	}
+	return total, true
+}
+
+// expandName makes name absolute relative to origin and drops the trailing dot.
+func expandName(name, origin string) string {
+	switch {
+	case name == "@":
+		return origin
+	case name == ".":
+		return "."
+	case strings.HasSuffix(name, "."):
+		return strings.TrimSuffix(name, ".")
+	case origin == "":
+		return name
+	}
+	return name + "." + origin
+}
+
+// absoluteName appends the trailing dot of a fully qualified name.
+func absoluteName(name string) string {
+	if name == "." || name == "" {
+		return "."
+	}
+	return strings.TrimSuffix(name, ".") + "."
+}
+
+// joinZoneTokens joins rdata fields with spaces, re-quoting quoted fields (or
+// all fields when quoteAll is set).
+func joinZoneTokens(tokens []zoneToken, quoteAll bool) string {
+	parts := make([]string, len(tokens))
+	for i, t := range tokens {
+		if t.quoted || quoteAll {
+			parts[i] = quoteZoneString(t.text)
+		} else {
+			parts[i] = t.text
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteTXT quotes s as one or more TXT character-strings of at most 255 bytes.
+func quoteTXT(s string) string {
+	if s == "" {
+		return `""`
+	}
+	var parts []string
+	for len(s) > maxTXTChunk {
+		parts = append(parts, quoteZoneString(s[:maxTXTChunk]))
+		s = s[maxTXTChunk:]
+	}
+	parts = append(parts, quoteZoneString(s))
+	return strings.Join(parts, " ")
+}
+
+// quoteZoneString quotes s as a master file character-string.
+func quoteZoneString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// dataString formats a structured data value, rendering JSON numbers without exponents.
+func dataString(v any) string {
+	switch n := v.(type) {
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func parseUint16(s string) (uint16, error) {
+	n, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return uint16(n), nil
+}
+
+func parseUint16s(tokens []zoneToken) ([]int, error) {
+	out := make([]int, len(tokens))
+	for i, t := range tokens {
+		n, err := parseUint16(t.text)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = int(n)
+	}
+	return out, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,167 @@
+package cloudflare
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+)
+
+// HeaderRequestID is the header RequestIDMiddleware sets on outgoing requests.
+const HeaderRequestID = "X-Request-ID"
+
+// redacted replaces the values of secret headers in dumps.
+const redacted = "[REDACTED]"
+
+// secretHeaders are never written by DumpMiddleware.
+var secretHeaders = []string{headerAuthz, headerAuthKey, "Cookie", "Set-Cookie"}
+
+// RoundTripFunc sends a single HTTP request. It is the unit middlewares wrap;
+// the innermost RoundTripFunc of a client is its http.Client's Do.
+type RoundTripFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// Middleware wraps a RoundTripFunc, e.g. to log, trace, measure or fail
+// requests. A middleware may inspect or modify the request before calling
+// next, return early without calling it, and inspect the response after.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// WithMiddleware appends middlewares to the client's chain. The first
+// middleware is the outermost: it sees the request first and the response
+// last. The chain runs once per attempt, after the auth headers are set and
+// the rate limiter admitted the request, so retries pass through it again.
+// Requests simulated in dry-run mode bypass it.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(o *Options) { o.Middleware = append(o.Middleware, mw...) }
+}
+
+// chain wraps rt in mw, with mw[0] outermost.
+func chain(mw []Middleware, rt RoundTripFunc) RoundTripFunc {
+	for i := len(mw) - 1; i >= 0; i-- {
+		rt = mw[i](rt)
+	}
+	return rt
+}
+
+// RequestIDMiddleware sets a random HeaderRequestID on every request that
+// does not carry one yet. Each attempt gets its own ID, so retries can be told
+// apart in proxy and server logs. Put it before DumpMiddleware to see the ID
+// in dumps.
+func RequestIDMiddleware() Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(HeaderRequestID) == "" {
+				id, err := newRequestID()
+				if err != nil {
+					return nil, err
+				}
+				req.Header.Set(HeaderRequestID, id)
+			}
+			return next(req)
+		}
+	}
+}
+
+// newRequestID returns 16 random bytes, hex-encoded.
+func newRequestID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("request id: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// DumpMiddleware writes every request and its response, headers and bodies,
+// to w in wire format, prefixing request lines with "> " and response lines
+// with "< ". The Authorization, X-Auth-Key and cookie headers are redacted.
+// Writes of concurrent requests do not interleave.
+func DumpMiddleware(w io.Writer) Middleware {
+	var mu sync.Mutex
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			reqDump, err := dumpRequest(req)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := next(req)
+			var respDump []byte
+			if err != nil {
+				respDump = []byte("error: " + err.Error())
+			} else if d, derr := dumpResponse(resp); derr != nil {
+				respDump = []byte("dump response: " + derr.Error())
+			} else {
+				respDump = d
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			writePrefixed(w, "> ", reqDump)
+			writePrefixed(w, "< ", respDump)
+			return resp, err
+		}
+	}
+}
+
+// dumpRequest dumps a redacted copy of req, leaving req's body readable.
+func dumpRequest(req *http.Request) ([]byte, error) {
+	body, err := bufferBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	cp := req.Clone(req.Context())
+	if body != nil {
+		cp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	redactHeaders(cp.Header)
+	return httputil.DumpRequestOut(cp, true)
+}
+
+// dumpResponse dumps a redacted copy of resp, leaving resp's body readable.
+func dumpResponse(resp *http.Response) ([]byte, error) {
+	body, err := bufferBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	cp := *resp
+	cp.Header = resp.Header.Clone()
+	cp.Body = io.NopCloser(bytes.NewReader(body))
+	redactHeaders(cp.Header)
+	return httputil.DumpResponse(&cp, true)
+}
+
+// bufferBody reads *body into memory and replaces it with a reader over the
+// same bytes.
+func bufferBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	b, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+func redactHeaders(h http.Header) {
+	for _, k := range secretHeaders {
+		if h.Get(k) != "" {
+			h.Set(k, redacted)
+		}
+	}
+}
+
+// writePrefixed writes b line by line to w, each line prefixed.
+func writePrefixed(w io.Writer, prefix string, b []byte) {
+	b = bytes.TrimRight(bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n")), "\n")
+	for line := range bytes.Lines(b) {
+		fmt.Fprintf(w, "%s%s", prefix, line)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,124 @@
+package cloudflare_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+func tagMiddleware(tag string, trace *[]string) cloudflare.Middleware {
+	return func(next cloudflare.RoundTripFunc) cloudflare.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			*trace = append(*trace, tag+" in")
+			resp, err := next(req)
+			*trace = append(*trace, tag+" out")
+			return resp, err
+		}
+	}
+}
+
+func TestMiddleware_OrderAndHeaders(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) { zoneOK(w) })
+	defer srv.Close()
+
+	var trace []string
+	var authz string
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL),
+		cloudflare.WithMiddleware(tagMiddleware("a", &trace), tagMiddleware("b", &trace)),
+		cloudflare.WithMiddleware(func(next cloudflare.RoundTripFunc) cloudflare.RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				authz = req.Header.Get("Authorization")
+				return next(req)
+			}
+		}),
+	)
+	_, err := c.FindZoneID(context.Background(), "example.com")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a in", "b in", "b out", "a out"}, trace)
+	require.Equal(t, "Bearer tok", authz)
+}
+
+func TestMiddleware_FaultInjectionIsRetried(t *testing.T) {
+	var served atomic.Int32
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		served.Add(1)
+		zoneOK(w)
+	})
+	defer srv.Close()
+
+	var calls int
+	fail := func(next cloudflare.RoundTripFunc) cloudflare.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			calls++
+			if calls == 1 {
+				return nil, errors.New("injected")
+			}
+			return next(req)
+		}
+	}
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL),
+		cloudflare.WithRetryPolicy(fastRetry()), cloudflare.WithMiddleware(fail))
+	_, err := c.FindZoneID(context.Background(), "example.com")
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+	require.Equal(t, int32(1), served.Load())
+}
+
+func TestDumpMiddleware_RedactsSecrets(t *testing.T) {
+	var gotBody string
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotBody = capture(r).Body
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cret"})
+		json.NewEncoder(w).Encode(map[string]any{"success": true, "result": map[string]any{"id": "r1", "type": "A", "name": "www.example.com", "content": "203.0.113.1"}})
+	})
+	defer srv.Close()
+
+	var dump bytes.Buffer
+	c := mustClient(t, cloudflare.WithGlobalKey("me@example.com", "key-value"), cloudflare.WithBaseURL(srv.URL),
+		cloudflare.WithMiddleware(cloudflare.RequestIDMiddleware(), cloudflare.DumpMiddleware(&dump)))
+	rec, err := c.CreateDNSRecord(context.Background(), "zid", cloudflare.DNSRecord{Type: "A", Name: "www.example.com", Content: "203.0.113.1", TTL: 1})
+	require.NoError(t, err)
+	require.Equal(t, "r1", rec.ID)
+	require.Contains(t, gotBody, `"content":"203.0.113.1"`, "body still reaches the server")
+
+	out := dump.String()
+	require.NotContains(t, out, "key-value")
+	require.NotContains(t, out, "s3cret")
+	require.Contains(t, out, "> X-Auth-Key: [REDACTED]")
+	require.Contains(t, out, "< Set-Cookie: [REDACTED]")
+	require.Contains(t, out, "> POST /zones/zid/dns_records HTTP/1.1")
+	require.Contains(t, out, `"content":"203.0.113.1"`)
+	require.Contains(t, out, "< HTTP/1.1 200 OK")
+	require.Regexp(t, regexp.MustCompile(`> X-Request-Id: [0-9a-f]{32}`), out)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		require.Regexp(t, `^[<>] `, line)
+	}
+}
+
+func TestRequestIDMiddleware_UniquePerRequest(t *testing.T) {
+	var ids []string
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get(cloudflare.HeaderRequestID))
+		zoneOK(w)
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL),
+		cloudflare.WithMiddleware(cloudflare.RequestIDMiddleware()))
+	for range 2 {
+		_, err := c.FindZoneID(context.Background(), "example.com")
+		require.NoError(t, err)
+	}
+	require.Len(t, ids, 2)
+	require.Len(t, ids[0], 32)
+	require.NotEqual(t, ids[0], ids[1])
+}
@@ -0,0 +1,117 @@
+package cloudflare
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ResultInfo is the pagination metadata returned by list endpoints.
+type ResultInfo struct {
+	Page       int     `json:"page"`
+	PerPage    int     `json:"per_page"`
+	Count      int     `json:"count"`
+	TotalCount int     `json:"total_count"`
+	TotalPages int     `json:"total_pages"`
+	Cursor     string  `json:"cursor,omitempty"`
+	Cursors    Cursors `json:"cursors"`
+}
+
+// Cursors holds the cursors of cursor-paginated list endpoints.
+type Cursors struct {
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// next returns the cursor of the following page, if any.
+func (ri *ResultInfo) next() string {
+	if ri.Cursors.After != "" {
+		return ri.Cursors.After
+	}
+	return ri.Cursor
+}
+
+// lastPage reports whether page is the final page in page-number pagination.
+func (ri *ResultInfo) lastPage(page, got int) bool {
+	switch {
+	case ri.TotalPages > 0:
+		return page >= ri.TotalPages
+	case ri.TotalCount > 0 && ri.PerPage > 0:
+		return page*ri.PerPage >= ri.TotalCount
+	case ri.PerPage > 0:
+		return got < ri.PerPage
+	}
+	return true
+}
+
+// Paginate returns an iterator over every item of the list endpoint at path,
+// fetching pages lazily as the caller ranges over it. Both page-number and
+// cursor pagination are supported: when a response carries a cursor the next
+// request uses it, otherwise the page parameter is incremented until
+// result_info reports the last page. An error ends the iteration after being
+// yielded once.
+func Paginate[T any](ctx context.Context, c *Client, path string, params url.Values) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		q := url.Values{}
+		for k, v := range params {
+			q[k] = append([]string(nil), v...)
+		}
+		page := 1
+		if p, err := strconv.Atoi(q.Get("page")); err == nil && p > 0 {
+			page = p
+		}
+		cursor := q.Get("cursor")
+		for {
+			if cursor != "" {
+				q.Del("page")
+				q.Set("cursor", cursor)
+			} else if page > 1 {
+				q.Set("page", strconv.Itoa(page))
+			}
+			u := path
+			if len(q) > 0 {
+				u += "?" + q.Encode()
+			}
+			out, err := request[[]T](ctx, c, http.MethodGet, u, nil)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range out.Result {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			info := out.ResultInfo
+			if info == nil || len(out.Result) == 0 {
+				return
+			}
+			if next := info.next(); next != "" {
+				if next == cursor {
+					return
+				}
+				cursor = next
+				continue
+			}
+			if cursor != "" || info.lastPage(page, len(out.Result)) {
+				return
+			}
+			page++
+		}
+	}
+}
+
+// Collect drains an iterator returned by Paginate or a List method into a slice.
+func Collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var items []T
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
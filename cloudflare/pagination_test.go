@@ -0,0 +1,117 @@
+package cloudflare_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	ID string `json:"id"`
+}
+
+func pageOf(start, n int) []item {
+	items := make([]item, 0, n)
+	for i := start; i < start+n; i++ {
+		items = append(items, item{ID: fmt.Sprintf("r%d", i)})
+	}
+	return items
+}
+
+func TestPaginate_PageNumbers(t *testing.T) {
+	const perPage, total = 2, 5
+	var pages []string
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/zones/zid/dns_records", r.URL.Path)
+		require.Equal(t, "A", r.URL.Query().Get("type"))
+		pages = append(pages, r.URL.Query().Get("page"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		page = max(page, 1)
+		start := (page - 1) * perPage
+		n := min(perPage, total-start)
+		json.NewEncoder(w).Encode(map[string]any{
+			"success": true,
+			"result":  pageOf(start, n),
+			"result_info": map[string]any{
+				"page": page, "per_page": perPage, "count": n, "total_count": total, "total_pages": 3,
+			},
+		})
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	items, err := cloudflare.Collect(cloudflare.Paginate[item](context.Background(), c, "zones/zid/dns_records", map[string][]string{"type": {"A"}}))
+	require.NoError(t, err)
+	require.Len(t, items, total)
+	require.Equal(t, "r4", items[4].ID)
+	require.Equal(t, []string{"", "2", "3"}, pages)
+}
+
+func TestPaginate_Cursor(t *testing.T) {
+	var cursors []string
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		cursor := r.URL.Query().Get("cursor")
+		cursors = append(cursors, cursor)
+		require.Empty(t, r.URL.Query().Get("page"))
+		info := map[string]any{"per_page": 2, "count": 2}
+		var result []item
+		switch cursor {
+		case "":
+			result = pageOf(0, 2)
+			info["cursors"] = map[string]any{"after": "c1"}
+		case "c1":
+			result = pageOf(2, 2)
+			info["cursors"] = map[string]any{"after": "c2"}
+		case "c2":
+			result = pageOf(4, 1)
+			info["count"] = 1
+		}
+		json.NewEncoder(w).Encode(map[string]any{"success": true, "result": result, "result_info": info})
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	items, err := cloudflare.Collect(cloudflare.Paginate[item](context.Background(), c, "things", nil))
+	require.NoError(t, err)
+	require.Len(t, items, 5)
+	require.Equal(t, []string{"", "c1", "c2"}, cursors)
+}
+
+func TestPaginate_StopsFetchingOnBreak(t *testing.T) {
+	calls := 0
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		json.NewEncoder(w).Encode(map[string]any{
+			"success":     true,
+			"result":      pageOf(0, 2),
+			"result_info": map[string]any{"page": calls, "per_page": 2, "count": 2, "total_pages": 10},
+		})
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	for it, err := range cloudflare.Paginate[item](context.Background(), c, "things", nil) {
+		require.NoError(t, err)
+		require.Equal(t, "r0", it.ID)
+		break
+	}
+	require.Equal(t, 1, calls)
+}
+
+func TestPaginate_ErrorIsYielded(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	_, err := cloudflare.Collect(c.ListZones(context.Background(), ""))
+	require.Error(t, err)
+	require.True(t, cloudflare.IsAuth(err))
+}
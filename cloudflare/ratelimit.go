@@ -0,0 +1,134 @@
+package cloudflare
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Header carrying Cloudflare's remaining quota, e.g. `"default";r=50;t=30`.
+const headerRateLimit = "Ratelimit"
+
+// WithRateLimit enables a client-side token bucket allowing rps requests per
+// second with bursts of up to burst requests. The bucket is shared by every
+// goroutine using the Client; callers block until a token is available or their
+// context is done. Cloudflare's global budget of 1200 requests per 5 minutes
+// corresponds to rps=4.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(o *Options) { o.RateLimit, o.RateBurst = rps, burst }
+}
+
+// rateLimiter is a token bucket that additionally pauses all callers when the
+// API reports an exhausted quota.
+type rateLimiter struct {
+	mu          sync.Mutex
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// newRateLimiter returns nil when rps is not positive, disabling limiting.
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	b := float64(max(burst, 1))
+	return &rateLimiter{rate: rps, burst: b, tokens: b, last: time.Now()}
+}
+
+// wait blocks until a token is available or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		delay := l.reserve(time.Now())
+		if delay == 0 {
+			return nil
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve takes a token and returns 0, or returns how long to wait before trying again.
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = min(l.burst, l.tokens+elapsed.Seconds()*l.rate)
+		l.last = now
+	}
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// observe adapts to rate limit signals in resp: a 429 with Retry-After, or a
+// Ratelimit header reporting no remaining requests, pauses all callers.
+func (l *rateLimiter) observe(resp *http.Response) {
+	if l == nil || resp == nil {
+		return
+	}
+	now := time.Now()
+	var pause time.Duration
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if d, ok := parseRetryAfter(resp.Header.Get(headerRetryAfter), now); ok {
+			pause = d
+		}
+	}
+	if remaining, reset, ok := parseRateLimitHeader(resp.Header.Get(headerRateLimit)); ok && remaining == 0 {
+		pause = max(pause, reset)
+	}
+	if pause <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := now.Add(pause); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+		l.tokens = 0
+		l.last = until
+	}
+}
+
+// parseRateLimitHeader extracts the remaining count (r) and reset delay (t) from
+// a structured Ratelimit header value such as `"default";r=0;t=30`.
+func parseRateLimitHeader(v string) (remaining int, reset time.Duration, ok bool) {
+	if v == "" {
+		return 0, 0, false
+	}
+	haveR := false
+	for _, part := range strings.Split(v, ";") {
+		key, val, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(val))
+		if err != nil || n < 0 {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "r":
+			remaining, haveR = n, true
+		case "t":
+			reset = time.Duration(n) * time.Second
+		}
+	}
+	return remaining, reset, haveR
+}
@@ -0,0 +1,86 @@
+package cloudflare_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+func zoneOK(w http.ResponseWriter) {
+	json.NewEncoder(w).Encode(map[string]any{"success": true, "result": []map[string]any{{"id": "z"}}})
+}
+
+func TestRateLimit_ConcurrentCallersShareBucket(t *testing.T) {
+	var calls atomic.Int32
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		zoneOK(w)
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL), cloudflare.WithRateLimit(20, 1))
+
+	start := time.Now()
+	errs := make(chan error, 5)
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.FindZoneID(context.Background(), "example.com")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// One token up front, then 4 more at 20/s: at least ~200ms.
+	require.GreaterOrEqual(t, time.Since(start), 180*time.Millisecond)
+	require.Equal(t, int32(5), calls.Load())
+}
+
+func TestRateLimit_BlockedCallerHonorsContext(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) { zoneOK(w) })
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL), cloudflare.WithRateLimit(0.1, 1))
+	_, err := c.FindZoneID(context.Background(), "example.com")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = c.FindZoneID(ctx, "example.com")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRateLimit_PausesOnExhaustedQuotaHeader(t *testing.T) {
+	var calls atomic.Int32
+	var second time.Time
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Ratelimit", `"default";r=0;t=1`)
+		} else {
+			second = time.Now()
+		}
+		zoneOK(w)
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL), cloudflare.WithRateLimit(100, 10))
+	start := time.Now()
+	_, err := c.FindZoneID(context.Background(), "example.com")
+	require.NoError(t, err)
+	_, err = c.FindZoneID(context.Background(), "example.com")
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, second.Sub(start), 900*time.Millisecond)
+}
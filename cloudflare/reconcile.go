@@ -0,0 +1,298 @@
+package cloudflare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// DefaultOwnerMarker is written to the comment of records created or updated
+// by Reconcile and identifies them as managed on later runs.
+const DefaultOwnerMarker = "managed-by:cloudflare-go"
+
+// ChangeAction is the kind of a planned change.
+type ChangeAction string
+
+const (
+	// ChangeCreate creates a desired record that does not exist.
+	ChangeCreate ChangeAction = "create"
+	// ChangeUpdate updates a managed record whose TTL, proxied flag, priority or comment differ.
+	ChangeUpdate ChangeAction = "update"
+	// ChangeDelete deletes a managed record that is no longer desired.
+	ChangeDelete ChangeAction = "delete"
+	// ChangeNoop means the record already matches.
+	ChangeNoop ChangeAction = "noop"
+	// ChangeSkip means a desired record matches an unmanaged record that is left alone.
+	ChangeSkip ChangeAction = "skip"
+)
+
+// Change is a single entry of a Plan.
+type Change struct {
+	Action ChangeAction `json:"action"`
+	// Desired is the wanted record; nil for deletes.
+	Desired *DNSRecord `json:"desired,omitempty"`
+	// Existing is the current record; nil for creates.
+	Existing *DNSRecord `json:"existing,omitempty"`
+}
+
+// Plan is the set of changes that makes a zone match the desired records.
+type Plan struct {
+	ZoneID  string   `json:"zone_id"`
+	Changes []Change `json:"changes"`
+}
+
+// ReconcileOptions configures Reconcile.
+type ReconcileOptions struct {
+	// ZoneName expands record labels to FQDNs; fetched by zone id when empty.
+	ZoneName string
+	// OwnerMarker marks managed records via their comment; DefaultOwnerMarker when empty.
+	OwnerMarker string
+	// ManageAll treats every record in the zone as managed, so records without
+	// the marker may be updated or deleted too.
+	ManageAll bool
+	// PlanOnly computes the plan without applying it.
+	PlanOnly bool
+}
+
+// Reconcile computes the changes that make the zone contain exactly the
+// desired records among those it manages and, unless opts.PlanOnly is set,
+// applies them. Records are keyed by type, name and content: a desired record
+// with no existing counterpart is created, a counterpart differing in TTL,
+// proxied, priority or comment is updated, and managed records not desired are
+// deleted. Records without the owner marker are never touched unless
+// opts.ManageAll is set. The returned plan is valid even when applying fails.
+func (c *Client) Reconcile(ctx context.Context, zoneID string, desired []DNSRecord, opts ReconcileOptions) (*Plan, error) {
+	if zoneID == "" {
+		return nil, errors.New("zoneID is required")
+	}
+	zoneName := opts.ZoneName
+	if zoneName == "" {
+		zone, err := c.GetZone(ctx, zoneID)
+		if err != nil {
+			return nil, err
+		}
+		zoneName = zone.Name
+	}
+	marker := opts.OwnerMarker
+	if marker == "" {
+		marker = DefaultOwnerMarker
+	}
+
+	existing, err := Collect(c.ListDNSRecords(ctx, zoneID, DNSRecordFilter{}))
+	if err != nil {
+		return nil, fmt.Errorf("reconcile lookup failed: %w", err)
+	}
+	plan, err := computePlan(zoneID, zoneName, marker, opts.ManageAll, existing, desired)
+	if err != nil {
+		return nil, err
+	}
+	if opts.PlanOnly {
+		return plan, nil
+	}
+	return plan, c.ApplyPlan(ctx, plan)
+}
+
+// computePlan diffs existing against desired without calling the API.
+func computePlan(zoneID, zoneName, marker string, manageAll bool, existing, desired []DNSRecord) (*Plan, error) {
+	managed := func(r DNSRecord) bool { return manageAll || strings.Contains(r.Comment, marker) }
+
+	byKey := map[string][]int{}
+	for i, r := range existing {
+		for _, k := range recordKeys(r) {
+			byKey[k] = append(byKey[k], i)
+		}
+	}
+
+	plan := &Plan{ZoneID: zoneID}
+	used := make([]bool, len(existing))
+	seen := map[string]bool{}
+	for _, d := range desired {
+		d.Type = strings.ToUpper(d.Type)
+		d.Name = FQDN(d.Name, zoneName)
+		if !strings.Contains(d.Comment, marker) {
+			d.Comment = strings.TrimSpace(d.Comment + " " + marker)
+		}
+		if err := ValidateDNSRecord(d); err != nil {
+			return nil, err
+		}
+		key := recordKeys(d)[0]
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate desired record %s %s %s", d.Type, d.Name, d.Content)
+		}
+		seen[key] = true
+
+		match := -1
+		for _, i := range byKey[key] {
+			if !used[i] {
+				match = i
+				break
+			}
+		}
+		if match < 0 {
+			plan.Changes = append(plan.Changes, Change{Action: ChangeCreate, Desired: &d})
+			continue
+		}
+		used[match] = true
+		cur := existing[match]
+		switch {
+		case RecordMatches(cur, d):
+			plan.Changes = append(plan.Changes, Change{Action: ChangeNoop, Desired: &d, Existing: &cur})
+		case !managed(cur):
+			plan.Changes = append(plan.Changes, Change{Action: ChangeSkip, Desired: &d, Existing: &cur})
+		default:
+			plan.Changes = append(plan.Changes, Change{Action: ChangeUpdate, Desired: &d, Existing: &cur})
+		}
+	}
+	for i, r := range existing {
+		if !used[i] && managed(r) {
+			plan.Changes = append(plan.Changes, Change{Action: ChangeDelete, Existing: &r})
+		}
+	}
+	sort.SliceStable(plan.Changes, func(i, j int) bool {
+		a, b := plan.Changes[i].record(), plan.Changes[j].record()
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Type < b.Type
+	})
+	return plan, nil
+}
+
+// recordKeys returns the identity keys of r: type, name and normalized
+// content, plus a key derived from structured data when present. The first
+// key is the primary one.
+func recordKeys(r DNSRecord) []string {
+	prefix := strings.ToUpper(r.Type) + "|" + strings.ToLower(strings.TrimSuffix(r.Name, ".")) + "|"
+	var keys []string
+	if r.Content != "" || r.Data == nil {
+		keys = append(keys, prefix+normalizeContent(r.Type, r.Content))
+	}
+	if r.Data != nil {
+		if n, err := normalizeJSON(r.Data); err == nil {
+			keys = append(keys, prefix+fmt.Sprint(n))
+		}
+	}
+	return keys
+}
+
+func normalizeContent(typ, content string) string {
+	switch strings.ToUpper(typ) {
+	case RecordTypeA, RecordTypeAAAA:
+		if ip := net.ParseIP(content); ip != nil {
+			return ip.String()
+		}
+	case RecordTypeCNAME, RecordTypeNS, RecordTypePTR, RecordTypeMX:
+		return strings.ToLower(strings.TrimSuffix(content, "."))
+	}
+	return content
+}
+
+// record returns the record a change is about, preferring the desired state.
+func (ch Change) record() DNSRecord {
+	if ch.Desired != nil {
+		return *ch.Desired
+	}
+	return *ch.Existing
+}
+
+// ApplyPlan executes the create, update and delete changes of plan. Deletes run
+// first so replacements (e.g. a CNAME taking over an A record's name) do not
+// conflict, then updates, then creates. It stops at the first failure.
+func (c *Client) ApplyPlan(ctx context.Context, plan *Plan) error {
+	for _, action := range []ChangeAction{ChangeDelete, ChangeUpdate, ChangeCreate} {
+		for _, ch := range plan.Changes {
+			if ch.Action != action {
+				continue
+			}
+			var err error
+			switch action {
+			case ChangeDelete:
+				err = c.DeleteDNSRecord(ctx, plan.ZoneID, ch.Existing.ID)
+			case ChangeUpdate:
+				_, err = c.UpdateDNSRecord(ctx, plan.ZoneID, ch.Existing.ID, *ch.Desired)
+			case ChangeCreate:
+				_, err = c.CreateDNSRecord(ctx, plan.ZoneID, *ch.Desired)
+			}
+			if err != nil {
+				r := ch.record()
+				return fmt.Errorf("%s %s %s: %w", action, r.Type, r.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Counts returns the number of changes per action.
+func (p *Plan) Counts() map[ChangeAction]int {
+	counts := map[ChangeAction]int{}
+	for _, ch := range p.Changes {
+		counts[ch.Action]++
+	}
+	return counts
+}
+
+// HasChanges reports whether applying the plan would modify the zone.
+func (p *Plan) HasChanges() bool {
+	counts := p.Counts()
+	return counts[ChangeCreate]+counts[ChangeUpdate]+counts[ChangeDelete] > 0
+}
+
+// String renders the plan as a diff: "+" create, "~" update, "-" delete and
+// "!" skipped unmanaged records, followed by a summary line. No-ops are only
+// counted.
+func (p *Plan) String() string {
+	var b strings.Builder
+	for _, ch := range p.Changes {
+		switch ch.Action {
+		case ChangeCreate:
+			fmt.Fprintf(&b, "+ %s\n", describeRecord(*ch.Desired))
+		case ChangeUpdate:
+			fmt.Fprintf(&b, "~ %s (%s)\n", describeRecord(*ch.Desired), describeDiff(*ch.Existing, *ch.Desired))
+		case ChangeDelete:
+			fmt.Fprintf(&b, "- %s\n", describeRecord(*ch.Existing))
+		case ChangeSkip:
+			fmt.Fprintf(&b, "! %s (unmanaged, left alone)\n", describeRecord(*ch.Existing))
+		}
+	}
+	counts := p.Counts()
+	fmt.Fprintf(&b, "Plan: %d to create, %d to update, %d to delete, %d unchanged, %d skipped.\n",
+		counts[ChangeCreate], counts[ChangeUpdate], counts[ChangeDelete], counts[ChangeNoop], counts[ChangeSkip])
+	return b.String()
+}
+
+func describeRecord(r DNSRecord) string {
+	value := r.Content
+	if value == "" && r.Data != nil {
+		if n, err := normalizeJSON(r.Data); err == nil {
+			value = fmt.Sprint(n)
+		}
+	}
+	s := fmt.Sprintf("%s %s %s ttl=%d", r.Type, r.Name, value, normalizeTTL(r.TTL))
+	if r.Priority != nil {
+		s += fmt.Sprintf(" priority=%d", *r.Priority)
+	}
+	if r.Proxied {
+		s += " proxied"
+	}
+	return s
+}
+
+func describeDiff(cur, want DNSRecord) string {
+	var diffs []string
+	if normalizeTTL(cur.TTL) != normalizeTTL(want.TTL) {
+		diffs = append(diffs, fmt.Sprintf("ttl %d -> %d", normalizeTTL(cur.TTL), normalizeTTL(want.TTL)))
+	}
+	if cur.Proxied != want.Proxied {
+		diffs = append(diffs, fmt.Sprintf("proxied %t -> %t", cur.Proxied, want.Proxied))
+	}
+	if want.Priority != nil && (cur.Priority == nil || *cur.Priority != *want.Priority) {
+		diffs = append(diffs, "priority changed")
+	}
+	if want.Comment != "" && cur.Comment != want.Comment {
+		diffs = append(diffs, fmt.Sprintf("comment %q -> %q", cur.Comment, want.Comment))
+	}
+	return strings.Join(diffs, ", ")
+}
@@ -0,0 +1,101 @@
+package cloudflare_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+const marker = cloudflare.DefaultOwnerMarker
+
+func zoneFixture() []cloudflare.DNSRecord {
+	return []cloudflare.DNSRecord{
+		{Type: "A", Name: "www.example.com", Content: "203.0.113.1", TTL: 300, Comment: marker},
+		{Type: "A", Name: "api.example.com", Content: "203.0.113.2", TTL: 1, Comment: marker},
+		{Type: "TXT", Name: "old.example.com", Content: "stale", TTL: 1, Comment: marker},
+		{Type: "MX", Name: "example.com", Content: "mx.example.com", TTL: 1, Priority: cloudflare.Ptr[uint16](10)},
+		{Type: "TXT", Name: "example.com", Content: "v=spf1 -all", TTL: 1},
+	}
+}
+
+func desiredFixture() []cloudflare.DNSRecord {
+	return []cloudflare.DNSRecord{
+		{Type: "A", Name: "www", Content: "203.0.113.1", TTL: 300},
+		{Type: "A", Name: "api", Content: "203.0.113.2", TTL: 600},
+		{Type: "AAAA", Name: "www", Content: "2001:db8::1", TTL: 300},
+		{Type: "TXT", Name: "@", Content: "v=spf1 -all", TTL: 1},
+	}
+}
+
+func TestReconcile_PlanOnly(t *testing.T) {
+	f, srv := newFakeDNS(t, "zid", "example.com", zoneFixture()...)
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+
+	plan, err := c.Reconcile(context.Background(), "zid", desiredFixture(), cloudflare.ReconcileOptions{PlanOnly: true})
+	require.NoError(t, err)
+	require.Empty(t, f.writes)
+	require.True(t, plan.HasChanges())
+
+	counts := plan.Counts()
+	require.Equal(t, 1, counts[cloudflare.ChangeCreate])
+	require.Equal(t, 1, counts[cloudflare.ChangeUpdate])
+	require.Equal(t, 1, counts[cloudflare.ChangeDelete])
+	require.Equal(t, 1, counts[cloudflare.ChangeNoop])
+	require.Equal(t, 1, counts[cloudflare.ChangeSkip])
+
+	out := plan.String()
+	require.Contains(t, out, "+ AAAA www.example.com 2001:db8::1 ttl=300")
+	require.Contains(t, out, "~ A api.example.com 203.0.113.2 ttl=600 (ttl 1 -> 600)")
+	require.Contains(t, out, "- TXT old.example.com stale")
+	require.Contains(t, out, "! TXT example.com v=spf1 -all")
+	require.NotContains(t, out, "mx.example.com", "unmanaged records that are not desired must not appear")
+	require.Contains(t, out, "Plan: 1 to create, 1 to update, 1 to delete, 1 unchanged, 1 skipped.")
+}
+
+func TestReconcile_ApplyConverges(t *testing.T) {
+	f, srv := newFakeDNS(t, "zid", "example.com", zoneFixture()...)
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	ctx := context.Background()
+
+	_, err := c.Reconcile(ctx, "zid", desiredFixture(), cloudflare.ReconcileOptions{ZoneName: "example.com"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"delete rec3", "update rec2", "create AAAA www.example.com"}, f.writes)
+
+	// MX and unmanaged TXT survive.
+	require.Len(t, f.records, 5)
+
+	plan, err := c.Reconcile(ctx, "zid", desiredFixture(), cloudflare.ReconcileOptions{ZoneName: "example.com"})
+	require.NoError(t, err)
+	require.False(t, plan.HasChanges())
+	require.Len(t, f.writes, 3)
+}
+
+func TestReconcile_ManageAllDeletesUnmarked(t *testing.T) {
+	_, srv := newFakeDNS(t, "zid", "example.com", zoneFixture()...)
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+
+	plan, err := c.Reconcile(context.Background(), "zid", desiredFixture(), cloudflare.ReconcileOptions{ManageAll: true, PlanOnly: true})
+	require.NoError(t, err)
+	counts := plan.Counts()
+	require.Equal(t, 2, counts[cloudflare.ChangeDelete])
+	require.Equal(t, 2, counts[cloudflare.ChangeUpdate])
+	require.Zero(t, counts[cloudflare.ChangeSkip])
+}
+
+func TestReconcile_RejectsDuplicatesAndInvalid(t *testing.T) {
+	_, srv := newFakeDNS(t, "zid", "example.com")
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	ctx := context.Background()
+
+	dup := []cloudflare.DNSRecord{
+		{Type: "A", Name: "www", Content: "203.0.113.1"},
+		{Type: "a", Name: "www.example.com.", Content: "203.0.113.1"},
+	}
+	_, err := c.Reconcile(ctx, "zid", dup, cloudflare.ReconcileOptions{PlanOnly: true})
+	require.ErrorContains(t, err, "duplicate desired record")
+
+	_, err = c.Reconcile(ctx, "zid", []cloudflare.DNSRecord{{Type: "A", Name: "x", Content: "nope"}}, cloudflare.ReconcileOptions{PlanOnly: true})
+	require.ErrorContains(t, err, "not an IPv4 address")
+}
@@ -0,0 +1,140 @@
+package cloudflare
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	headerRetryAfter = "Retry-After"
+
+	// Retry defaults
+	defaultMaxRetries = 3
+	defaultMinBackoff = 500 * time.Millisecond
+	defaultMaxBackoff = 30 * time.Second
+)
+
+// RetryPolicy controls how Client.do retries failed requests. The zero value
+// disables retries.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the initial attempt.
+	MaxRetries int
+	// MinBackoff is the base delay for the first retry; it doubles per attempt.
+	MinBackoff time.Duration
+	// MaxBackoff caps the computed delay. A Retry-After header may exceed it.
+	MaxBackoff time.Duration
+	// RetryNonIdempotent also retries POST and PATCH requests. By default only
+	// idempotent methods (GET, HEAD, OPTIONS, PUT, DELETE) are retried.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy returns a policy with 3 retries and jittered exponential
+// backoff between 500ms and 30s.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: defaultMaxRetries,
+		MinBackoff: defaultMinBackoff,
+		MaxBackoff: defaultMaxBackoff,
+	}
+}
+
+// WithRetryPolicy enables retries of transient failures (network errors, 429 and 5xx).
+func WithRetryPolicy(p RetryPolicy) Option { return func(o *Options) { o.RetryPolicy = p } }
+
+// retryable reports whether the outcome of an attempt for req should be retried.
+func (p RetryPolicy) retryable(ctx context.Context, req *http.Request, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if !p.RetryNonIdempotent {
+		switch req.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		default:
+			return false
+		}
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before retry number attempt (0-based). A valid
+// Retry-After header on resp takes precedence over the computed delay.
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get(headerRetryAfter), time.Now()); ok {
+			return d
+		}
+	}
+	minBackoff, maxBackoff := p.MinBackoff, p.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultMinBackoff
+	}
+	if maxBackoff < minBackoff {
+		maxBackoff = minBackoff
+	}
+	d := minBackoff
+	for i := 0; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, maxBackoff)
+	// Equal jitter: keep half the delay, randomize the other half.
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+// parseRetryAfter parses a Retry-After value in delay-seconds or HTTP-date form.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// sleepCtx waits for d or until ctx is done. It returns false without waiting
+// if ctx's deadline would pass before d elapses.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return false
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// drainBody discards and closes a response body so the connection can be reused.
+func drainBody(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,146 @@
+package cloudflare_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+func fastRetry() cloudflare.RetryPolicy {
+	return cloudflare.RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+}
+
+func TestRetry_TransientStatusThenSuccess(t *testing.T) {
+	var calls atomic.Int32
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"success": true,
+			"result":  []map[string]any{{"id": "abc123", "name": "example.com"}},
+		})
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL), cloudflare.WithRetryPolicy(fastRetry()))
+	id, err := c.FindZoneID(context.Background(), "example.com")
+	require.NoError(t, err)
+	require.Equal(t, "abc123", id)
+	require.Equal(t, int32(3), calls.Load())
+}
+
+func TestRetry_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL), cloudflare.WithRetryPolicy(fastRetry()))
+	_, err := c.FindZoneID(context.Background(), "example.com")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "503")
+	require.Equal(t, int32(4), calls.Load())
+}
+
+func TestRetry_DisabledByDefault(t *testing.T) {
+	var calls atomic.Int32
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	_, err := c.FindZoneID(context.Background(), "example.com")
+	require.Error(t, err)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetry_PostNotRetriedByDefault(t *testing.T) {
+	var calls atomic.Int32
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL), cloudflare.WithRetryPolicy(fastRetry()))
+	_, err := c.CreateARecord(context.Background(), "zid", cloudflare.DNSRecord{Type: "A", Name: "home", Content: "203.0.113.1", TTL: 1})
+	require.Error(t, err)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetry_PutReplaysBody(t *testing.T) {
+	var bodies []string
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"success": true, "result": map[string]any{"id": "rid"}})
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL), cloudflare.WithRetryPolicy(fastRetry()))
+	_, err := c.UpdateARecord(context.Background(), "zid", "rid", cloudflare.DNSRecord{Type: "A", Name: "home", Content: "203.0.113.1", TTL: 1})
+	require.NoError(t, err)
+	require.Len(t, bodies, 2)
+	require.NotEmpty(t, bodies[0])
+	require.Equal(t, bodies[0], bodies[1])
+}
+
+func TestRetry_RetryAfterBeyondDeadlineStops(t *testing.T) {
+	var calls atomic.Int32
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL), cloudflare.WithRetryPolicy(fastRetry()))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.FindZoneID(ctx, "example.com")
+	require.Error(t, err)
+	require.True(t, cloudflare.IsRateLimited(err))
+	require.Equal(t, int32(1), calls.Load())
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetry_HonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	var first time.Time
+	var elapsed time.Duration
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		elapsed = time.Since(first)
+		json.NewEncoder(w).Encode(map[string]any{"success": true, "result": []map[string]any{{"id": "z"}}})
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL), cloudflare.WithRetryPolicy(fastRetry()))
+	_, err := c.FindZoneID(context.Background(), "example.com")
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, elapsed, 900*time.Millisecond)
+}
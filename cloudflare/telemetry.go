@@ -0,0 +1,288 @@
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header carrying the W3C trace context of outgoing requests.
+const headerTraceParent = "traceparent"
+
+// Metric names reported to a Meter, once per API call (retries included).
+const (
+	// MetricRequests counts API calls.
+	MetricRequests = "cloudflare.client.requests"
+	// MetricErrors counts failed API calls: transport errors, non-2xx statuses.
+	MetricErrors = "cloudflare.client.errors"
+	// MetricRequestDuration records the latency of API calls in seconds.
+	MetricRequestDuration = "cloudflare.client.request.duration"
+)
+
+// Attribute keys set on spans and metrics. They follow the OpenTelemetry HTTP
+// semantic conventions where one exists.
+const (
+	AttrMethod     = "http.request.method"
+	AttrTemplate   = "url.template"
+	AttrStatusCode = "http.response.status_code"
+	AttrRetries    = "http.request.resend_count"
+	AttrErrorType  = "error.type"
+	AttrErrorCode  = "cloudflare.error_code"  // metrics: first Cloudflare error code
+	AttrErrorCodes = "cloudflare.error_codes" // spans: every Cloudflare error code
+	AttrDryRun     = "cloudflare.dry_run"
+)
+
+// Attribute is a key-value pair describing a span or a measurement. Values are
+// strings, ints, bools or []int.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Tracer starts spans. Implementations bridge to a tracing SDK such as
+// OpenTelemetry; InMemoryExporter is one for tests.
+type Tracer interface {
+	// Start starts a span as a child of the span in ctx, if any, and returns
+	// a context carrying the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a single traced operation.
+type Span interface {
+	// SpanContext identifies the span; a valid one is propagated as the
+	// traceparent header.
+	SpanContext() SpanContext
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Meter receives measurements. Implementations bridge to a metrics SDK.
+type Meter interface {
+	// Add adds delta to the counter name.
+	Add(ctx context.Context, name string, delta int64, attrs ...Attribute)
+	// Record records value in the histogram name.
+	Record(ctx context.Context, name string, value float64, attrs ...Attribute)
+}
+
+// WithTracer records a span per API call, named "METHOD template" (e.g.
+// "GET zones/{zone_id}/dns_records"), covering all of its retries, and
+// propagates it to Cloudflare in the W3C traceparent header.
+func WithTracer(t Tracer) Option { return func(o *Options) { o.Tracer = t } }
+
+// WithMeter reports MetricRequests, MetricErrors and MetricRequestDuration
+// per API call.
+func WithMeter(m Meter) Option { return func(o *Options) { o.Meter = m } }
+
+// SpanContext is the W3C trace context of a span.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid reports whether both ids are non-zero.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceParent formats sc as a version 00 traceparent header value.
+func (sc SpanContext) TraceParent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+// ParseTraceParent parses a traceparent header value, e.g. one received by a
+// service, so InMemoryExporter-style tracers can continue the trace via
+// ContextWithSpanContext.
+func ParseTraceParent(s string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, fmt.Errorf("invalid traceparent %q", s)
+	}
+	tid, err1 := hex.DecodeString(parts[1])
+	sid, err2 := hex.DecodeString(parts[2])
+	flags, err3 := strconv.ParseUint(parts[3], 16, 8)
+	if err := errors.Join(err1, err2, err3); err != nil || len(tid) != 16 || len(sid) != 8 || len(parts[3]) != 2 {
+		return sc, fmt.Errorf("invalid traceparent %q", s)
+	}
+	copy(sc.TraceID[:], tid)
+	copy(sc.SpanID[:], sid)
+	sc.Sampled = flags&1 == 1
+	if !sc.IsValid() {
+		return sc, fmt.Errorf("invalid traceparent %q: zero id", s)
+	}
+	return sc, nil
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns a context carrying sc as the current span.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context stored by
+// ContextWithSpanContext, if any.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// instrument sends req like send, wrapped in a span and measurements.
+func (c *Client) instrument(ctx context.Context, req *http.Request) (*http.Response, error) {
+	method := req.Method
+	template := endpointTemplate(c.baseURL.Path, req.URL.Path)
+	attrs := []Attribute{{AttrMethod, method}, {AttrTemplate, template}}
+
+	var span Span
+	var sc SpanContext
+	if c.tracer != nil {
+		ctx, span = c.tracer.Start(ctx, method+" "+template)
+		sc = span.SpanContext()
+	}
+	start := time.Now()
+	resp, retries, err := c.send(ctx, req, sc)
+	elapsed := time.Since(start)
+
+	errType := ""
+	var codes []int
+	switch {
+	case err != nil:
+		errType = transportErrorType(err)
+	case resp.StatusCode >= 400:
+		errType = strconv.Itoa(resp.StatusCode)
+		codes = peekErrorCodes(resp)
+	}
+	if resp != nil {
+		attrs = append(attrs, Attribute{AttrStatusCode, resp.StatusCode})
+	}
+	if errType != "" {
+		attrs = append(attrs, Attribute{AttrErrorType, errType})
+	}
+
+	if span != nil {
+		span.SetAttributes(attrs...)
+		span.SetAttributes(Attribute{AttrRetries, retries})
+		if c.dryRun && mutating(method) {
+			span.SetAttributes(Attribute{AttrDryRun, true})
+		}
+		if len(codes) > 0 {
+			span.SetAttributes(Attribute{AttrErrorCodes, codes})
+		}
+		switch {
+		case err != nil:
+			span.RecordError(err)
+		case errType != "":
+			span.RecordError(fmt.Errorf("%s %s: %d %s", method, req.URL.Path, resp.StatusCode, http.StatusText(resp.StatusCode)))
+		}
+		span.End()
+	}
+	if c.meter != nil {
+		c.meter.Add(ctx, MetricRequests, 1, attrs...)
+		c.meter.Record(ctx, MetricRequestDuration, elapsed.Seconds(), attrs...)
+		if errType != "" {
+			errAttrs := attrs
+			if len(codes) > 0 {
+				errAttrs = append(errAttrs[:len(errAttrs):len(errAttrs)], Attribute{AttrErrorCode, codes[0]})
+			}
+			c.meter.Add(ctx, MetricErrors, 1, errAttrs...)
+		}
+	}
+	return resp, err
+}
+
+// endpointTemplate turns a request path into a low-cardinality template by
+// replacing the ids that follow collection segments, e.g.
+// "/client/v4/zones/023e/dns_records/372e" becomes
+// "zones/{zone_id}/dns_records/{dns_record_id}".
+func endpointTemplate(basePath, p string) string {
+	p = strings.TrimPrefix(p, strings.TrimSuffix(basePath, "/"))
+	segs := strings.Split(strings.Trim(p, "/"), "/")
+	for i := 1; i < len(segs); i++ {
+		if id, ok := idPlaceholders[segs[i-1]]; ok && !endpointActions[segs[i]] {
+			segs[i] = id
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+// idPlaceholders maps collection segments to the placeholder of the id that
+// may follow them.
+var idPlaceholders = map[string]string{
+	"zones":       "{zone_id}",
+	"dns_records": "{dns_record_id}",
+	"accounts":    "{account_id}",
+	"tokens":      "{token_id}",
+}
+
+// endpointActions are fixed path segments that follow a collection segment
+// in place of an id.
+var endpointActions = map[string]bool{
+	"export": true,
+	"import": true,
+	"batch":  true,
+	"verify": true,
+}
+
+// transportErrorType classifies an error that produced no response.
+func transportErrorType(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+	return "transport"
+}
+
+// maxErrorPeek bounds how much of an error response is buffered to extract
+// Cloudflare error codes.
+const maxErrorPeek = 1 << 20
+
+// peekErrorCodes returns the error codes of the Cloudflare envelope in resp,
+// leaving the body readable for the caller.
+func peekErrorCodes(resp *http.Response) []int {
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorPeek))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(b), resp.Body), resp.Body}
+	if err != nil {
+		return nil
+	}
+	var env apiResponse[json.RawMessage]
+	if json.Unmarshal(b, &env) != nil {
+		return nil
+	}
+	codes := make([]int, 0, len(env.Errors))
+	for _, e := range env.Errors {
+		codes = append(codes, e.Code)
+	}
+	return codes
+}
+
+// newSpanContext returns a span context with a fresh span id, in the trace of
+// parent and inheriting its sampling decision if it is valid, and sampled in
+// a new trace otherwise.
+func newSpanContext(parent SpanContext) SpanContext {
+	sc := SpanContext{TraceID: parent.TraceID, Sampled: !parent.IsValid() || parent.Sampled}
+	if !parent.IsValid() {
+		rand.Read(sc.TraceID[:])
+	}
+	rand.Read(sc.SpanID[:])
+	return sc
+}
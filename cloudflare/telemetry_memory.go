@@ -0,0 +1,161 @@
+package cloudflare
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// InMemoryExporter is a Tracer and Meter that keeps finished spans and
+// measurements in memory, for tests and debugging. Spans started from a
+// context carrying a span context (see ContextWithSpanContext) join its
+// trace. It is safe for concurrent use.
+type InMemoryExporter struct {
+	mu      sync.Mutex
+	spans   []RecordedSpan
+	metrics []Measurement
+}
+
+// RecordedSpan is a finished span recorded by InMemoryExporter.
+type RecordedSpan struct {
+	Name        string
+	SpanContext SpanContext
+	// Parent is the zero SpanContext for root spans.
+	Parent     SpanContext
+	Attributes map[string]any
+	Errors     []error
+	Start, End time.Time
+}
+
+// Measurement is a counter increment or histogram observation recorded by
+// InMemoryExporter.
+type Measurement struct {
+	Name string
+	// Histogram distinguishes Record (true) from Add (false).
+	Histogram  bool
+	Value      float64
+	Attributes map[string]any
+}
+
+// NewInMemoryExporter returns an empty InMemoryExporter.
+func NewInMemoryExporter() *InMemoryExporter { return &InMemoryExporter{} }
+
+// Start implements Tracer.
+func (e *InMemoryExporter) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := SpanContextFromContext(ctx)
+	s := &memorySpan{exporter: e, rec: RecordedSpan{
+		Name:        name,
+		SpanContext: newSpanContext(parent),
+		Parent:      parent,
+		Attributes:  map[string]any{},
+		Start:       time.Now(),
+	}}
+	return ContextWithSpanContext(ctx, s.rec.SpanContext), s
+}
+
+// Add implements Meter.
+func (e *InMemoryExporter) Add(_ context.Context, name string, delta int64, attrs ...Attribute) {
+	e.measure(Measurement{Name: name, Value: float64(delta), Attributes: attrMap(attrs)})
+}
+
+// Record implements Meter.
+func (e *InMemoryExporter) Record(_ context.Context, name string, value float64, attrs ...Attribute) {
+	e.measure(Measurement{Name: name, Histogram: true, Value: value, Attributes: attrMap(attrs)})
+}
+
+func (e *InMemoryExporter) measure(m Measurement) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.metrics = append(e.metrics, m)
+}
+
+// Spans returns the finished spans in the order they ended.
+func (e *InMemoryExporter) Spans() []RecordedSpan {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]RecordedSpan(nil), e.spans...)
+}
+
+// Measurements returns the recorded measurements in order.
+func (e *InMemoryExporter) Measurements() []Measurement {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Measurement(nil), e.metrics...)
+}
+
+// Sum returns the total of the measurements named name whose attributes
+// include every attribute in match.
+func (e *InMemoryExporter) Sum(name string, match ...Attribute) float64 {
+	var sum float64
+	for _, m := range e.Measurements() {
+		if m.Name == name && hasAttributes(m.Attributes, match) {
+			sum += m.Value
+		}
+	}
+	return sum
+}
+
+// Reset discards everything recorded so far.
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans, e.metrics = nil, nil
+}
+
+// memorySpan is the Span handed out by InMemoryExporter.
+type memorySpan struct {
+	exporter *InMemoryExporter
+	mu       sync.Mutex
+	rec      RecordedSpan
+	ended    bool
+}
+
+func (s *memorySpan) SpanContext() SpanContext { return s.rec.SpanContext }
+
+func (s *memorySpan) SetAttributes(attrs ...Attribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range attrs {
+		s.rec.Attributes[a.Key] = a.Value
+	}
+}
+
+func (s *memorySpan) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rec.Errors = append(s.rec.Errors, err)
+}
+
+func (s *memorySpan) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.rec.End = time.Now()
+	rec := s.rec
+	s.mu.Unlock()
+
+	s.exporter.mu.Lock()
+	defer s.exporter.mu.Unlock()
+	s.exporter.spans = append(s.exporter.spans, rec)
+}
+
+func attrMap(attrs []Attribute) map[string]any {
+	m := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		m[a.Key] = a.Value
+	}
+	return m
+}
+
+func hasAttributes(m map[string]any, match []Attribute) bool {
+	for _, a := range match {
+		if v, ok := m[a.Key]; !ok || !reflect.DeepEqual(v, a.Value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package cloudflare_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelemetry_SpanPerCallWithRetriesAndTraceParent(t *testing.T) {
+	var calls atomic.Int32
+	var traceparents []string
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"success": true, "result": []any{}})
+	})
+	defer srv.Close()
+
+	exp := cloudflare.NewInMemoryExporter()
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL),
+		cloudflare.WithRetryPolicy(fastRetry()), cloudflare.WithTracer(exp), cloudflare.WithMeter(exp))
+
+	parent, err := cloudflare.ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	ctx := cloudflare.ContextWithSpanContext(context.Background(), parent)
+	_, err = cloudflare.Collect(c.ListDNSRecords(ctx, "023e105f4ecef8ad9ca31a8372d0c353", cloudflare.DNSRecordFilter{}))
+	require.NoError(t, err)
+
+	spans := exp.Spans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	require.Equal(t, "GET zones/{zone_id}/dns_records", s.Name)
+	require.Equal(t, parent, s.Parent)
+	require.Equal(t, parent.TraceID, s.SpanContext.TraceID)
+	require.Equal(t, "zones/{zone_id}/dns_records", s.Attributes[cloudflare.AttrTemplate])
+	require.Equal(t, http.MethodGet, s.Attributes[cloudflare.AttrMethod])
+	require.Equal(t, 200, s.Attributes[cloudflare.AttrStatusCode])
+	require.Equal(t, 1, s.Attributes[cloudflare.AttrRetries])
+	require.Empty(t, s.Errors)
+
+	// Both attempts carry the call's span as their parent.
+	require.Equal(t, []string{s.SpanContext.TraceParent(), s.SpanContext.TraceParent()}, traceparents)
+
+	require.Equal(t, 1.0, exp.Sum(cloudflare.MetricRequests, cloudflare.Attribute{Key: cloudflare.AttrTemplate, Value: "zones/{zone_id}/dns_records"}))
+	require.Equal(t, 0.0, exp.Sum(cloudflare.MetricErrors))
+	var durations int
+	for _, m := range exp.Measurements() {
+		if m.Name == cloudflare.MetricRequestDuration {
+			durations++
+			require.True(t, m.Histogram)
+			require.Greater(t, m.Value, 0.0)
+		}
+	}
+	require.Equal(t, 1, durations)
+}
+
+func TestTelemetry_ErrorCodes(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]any{"success": false, "errors": []map[string]any{{"code": 81044, "message": "Record does not exist."}}})
+	})
+	defer srv.Close()
+
+	exp := cloudflare.NewInMemoryExporter()
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL), cloudflare.WithTracer(exp), cloudflare.WithMeter(exp))
+	_, err := c.GetDNSRecord(context.Background(), "zid", "rid")
+	require.True(t, cloudflare.IsNotFound(err), "error envelope is still decoded: %v", err)
+
+	s := exp.Spans()[0]
+	require.Equal(t, "GET zones/{zone_id}/dns_records/{dns_record_id}", s.Name)
+	require.Equal(t, []int{81044}, s.Attributes[cloudflare.AttrErrorCodes])
+	require.Equal(t, "404", s.Attributes[cloudflare.AttrErrorType])
+	require.Len(t, s.Errors, 1)
+	require.Equal(t, 1.0, exp.Sum(cloudflare.MetricErrors, cloudflare.Attribute{Key: cloudflare.AttrErrorCode, Value: 81044}))
+}
+
+func TestTelemetry_EndpointTemplates(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/zones/zid/dns_records/export" {
+			w.Write([]byte("www.example.com.\t1\tIN\tA\t203.0.113.1\n"))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"success": true, "result": map[string]any{"id": "rid"}})
+	})
+	defer srv.Close()
+
+	exp := cloudflare.NewInMemoryExporter()
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL), cloudflare.WithTracer(exp))
+	ctx := context.Background()
+	_, err := c.ExportZoneFile(ctx, "zid")
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteDNSRecord(ctx, "zid", "rid"))
+
+	var names []string
+	for _, s := range exp.Spans() {
+		names = append(names, s.Name)
+		require.False(t, s.Parent.IsValid())
+	}
+	require.Equal(t, []string{"GET zones/{zone_id}/dns_records/export", "DELETE zones/{zone_id}/dns_records/{dns_record_id}"}, names)
+}
+
+func TestParseTraceParent(t *testing.T) {
+	sc, err := cloudflare.ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	require.NoError(t, err)
+	require.False(t, sc.Sampled)
+	require.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", sc.TraceParent())
+
+	for _, bad := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-xyz92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		_, err := cloudflare.ParseTraceParent(bad)
+		require.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,116 @@
+package cloudflare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// API token statuses reported by VerifyToken.
+const (
+	TokenActive   = "active"
+	TokenDisabled = "disabled"
+	TokenExpired  = "expired"
+)
+
+// ErrNotAPIToken is returned by VerifyToken when the client authenticates
+// with a Global API Key, which cannot be verified.
+var ErrNotAPIToken = errors.New("credentials are not an API token")
+
+// TokenVerification describes the API token the client authenticates with.
+type TokenVerification struct {
+	ID string `json:"id"`
+	// Status is TokenActive, TokenDisabled or TokenExpired.
+	Status string `json:"status"`
+	// ExpiresOn and NotBefore bound the token's validity; nil if unset.
+	ExpiresOn *time.Time `json:"expires_on,omitempty"`
+	NotBefore *time.Time `json:"not_before,omitempty"`
+}
+
+// VerifyToken checks the client's API token with /user/tokens/verify. A
+// token that is disabled or expired is reported in Status rather than as an
+// error; invalid tokens fail with an auth error (see IsAuth).
+func (c *Client) VerifyToken(ctx context.Context) (*TokenVerification, error) {
+	creds, err := c.creds.Credentials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("resolving credentials: %w", err)
+	}
+	if mode, err := creds.mode(); err != nil {
+		return nil, err
+	} else if mode != AuthAPIToken {
+		return nil, ErrNotAPIToken
+	}
+	out, err := request[TokenVerification](ctx, c, http.MethodGet, "user/tokens/verify", nil)
+	if err != nil {
+		return nil, fmt.Errorf("verify token failed: %w", err)
+	}
+	return &out.Result, nil
+}
+
+// permDNSRecordsEdit is the zone permission that allows writing DNS records.
+const permDNSRecordsEdit = "#dns_records:edit"
+
+// ErrDryRun is returned by checks that would have to send a mutating request
+// while the client is in dry-run mode.
+var ErrDryRun = errors.New("skipped in dry-run mode")
+
+// Ways CanEditDNS learns about DNS write access, reported in DNSEditCheck.
+const (
+	DNSEditFromPermissions = "permissions"
+	DNSEditFromProbe       = "probe"
+)
+
+// DNSEditCheck is the result of CanEditDNS.
+type DNSEditCheck struct {
+	// Allowed reports whether the credentials may write DNS records.
+	Allowed bool
+	// Source is DNSEditFromPermissions or DNSEditFromProbe.
+	Source string
+	// Deleted is the id of a record the probe created against expectations
+	// and CanEditDNS deleted again; empty otherwise.
+	Deleted string
+}
+
+// CanEditDNS reports whether the credentials may write DNS records of the
+// zone. It reads the permissions Cloudflare lists on the zone when present.
+// Otherwise it probes by creating a record with no type or name, which
+// Cloudflare rejects as invalid (400) only once the write is authorized, and
+// with an auth error otherwise; should the probe ever create a record, it is
+// deleted again and reported in Deleted. The probe is not sent in dry-run
+// mode, where CanEditDNS fails with ErrDryRun instead.
+func (c *Client) CanEditDNS(ctx context.Context, zoneID string) (*DNSEditCheck, error) {
+	if zoneID == "" {
+		return nil, errors.New("zoneID is required")
+	}
+	zone, err := c.GetZone(ctx, zoneID)
+	if err != nil {
+		return nil, fmt.Errorf("dns edit check failed: %w", err)
+	}
+	if len(zone.Permissions) > 0 {
+		return &DNSEditCheck{Allowed: slices.Contains(zone.Permissions, permDNSRecordsEdit), Source: DNSEditFromPermissions}, nil
+	}
+	if c.dryRun {
+		return nil, ErrDryRun
+	}
+
+	check := &DNSEditCheck{Source: DNSEditFromProbe}
+	out, err := request[DNSRecord](ctx, c, http.MethodPost, "zones/"+zoneID+"/dns_records", struct{}{})
+	var apiErr *APIError
+	switch {
+	case IsAuth(err):
+		return check, nil
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest:
+		check.Allowed = true
+		return check, nil
+	case err != nil:
+		return nil, fmt.Errorf("dns edit check failed: %w", err)
+	}
+	check.Allowed, check.Deleted = true, out.Result.ID
+	if err := c.DeleteDNSRecord(ctx, zoneID, out.Result.ID); err != nil {
+		return check, fmt.Errorf("dns edit probe created record %s, deleting it failed: %w", out.Result.ID, err)
+	}
+	return check, nil
+}
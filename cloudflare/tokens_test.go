@@ -0,0 +1,146 @@
+package cloudflare_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyToken(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, "/user/tokens/verify", r.URL.Path)
+		require.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"success":true,"result":{"id":"ed17574386854bf78a67040be0a770b0","status":"active","expires_on":"2026-11-01T00:00:00Z","not_before":"2026-01-01T00:00:00Z"}}`))
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	v, err := c.VerifyToken(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "ed17574386854bf78a67040be0a770b0", v.ID)
+	require.Equal(t, cloudflare.TokenActive, v.Status)
+	require.Equal(t, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), v.ExpiresOn.UTC())
+	require.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), v.NotBefore.UTC())
+}
+
+func TestVerifyToken_InvalidToken(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"success":false,"errors":[{"code":1000,"message":"Invalid API Token"}]}`))
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("bad"), cloudflare.WithBaseURL(srv.URL))
+	_, err := c.VerifyToken(context.Background())
+	require.True(t, cloudflare.IsAuth(err))
+}
+
+func TestVerifyToken_GlobalKey(t *testing.T) {
+	c := mustClient(t, cloudflare.WithGlobalKey("me@example.com", "key"), cloudflare.WithBaseURL("http://127.0.0.1:1"))
+	_, err := c.VerifyToken(context.Background())
+	require.ErrorIs(t, err, cloudflare.ErrNotAPIToken)
+}
+
+func TestCanEditDNS(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions string
+		status      int
+		body        string
+		want        cloudflare.DNSEditCheck
+		wantErr     string
+		wantCalls   []string
+	}{
+		{
+			name:        "permissions allow",
+			permissions: `["#zone:read","#dns_records:read","#dns_records:edit"]`,
+			want:        cloudflare.DNSEditCheck{Allowed: true, Source: cloudflare.DNSEditFromPermissions},
+			wantCalls:   []string{"GET /zones/z1"},
+		},
+		{
+			name:        "permissions deny",
+			permissions: `["#zone:read","#dns_records:read"]`,
+			want:        cloudflare.DNSEditCheck{Source: cloudflare.DNSEditFromPermissions},
+			wantCalls:   []string{"GET /zones/z1"},
+		},
+		{
+			name:      "probe authorized",
+			status:    http.StatusBadRequest,
+			body:      `{"success":false,"errors":[{"code":9000,"message":"DNS name is invalid."}]}`,
+			want:      cloudflare.DNSEditCheck{Allowed: true, Source: cloudflare.DNSEditFromProbe},
+			wantCalls: []string{"GET /zones/z1", "POST /zones/z1/dns_records"},
+		},
+		{
+			name:      "probe forbidden",
+			status:    http.StatusForbidden,
+			body:      `{"success":false,"errors":[{"code":10000,"message":"Authentication error"}]}`,
+			want:      cloudflare.DNSEditCheck{Source: cloudflare.DNSEditFromProbe},
+			wantCalls: []string{"GET /zones/z1", "POST /zones/z1/dns_records"},
+		},
+		{
+			name:      "probe created a record",
+			status:    http.StatusOK,
+			body:      `{"success":true,"result":{"id":"r9","type":"A","name":"example.com"}}`,
+			want:      cloudflare.DNSEditCheck{Allowed: true, Source: cloudflare.DNSEditFromProbe, Deleted: "r9"},
+			wantCalls: []string{"GET /zones/z1", "POST /zones/z1/dns_records", "DELETE /zones/z1/dns_records/r9"},
+		},
+		{
+			name:      "server error",
+			status:    http.StatusInternalServerError,
+			body:      `{"success":false}`,
+			wantErr:   "dns edit check failed",
+			wantCalls: []string{"GET /zones/z1", "POST /zones/z1/dns_records"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, r.Method+" "+r.URL.Path)
+				switch r.Method {
+				case http.MethodGet:
+					perms := tt.permissions
+					if perms == "" {
+						perms = "[]"
+					}
+					w.Write([]byte(`{"success":true,"result":{"id":"z1","name":"example.com","permissions":` + perms + `}}`))
+				case http.MethodPost:
+					w.WriteHeader(tt.status)
+					w.Write([]byte(tt.body))
+				case http.MethodDelete:
+					w.Write([]byte(`{"success":true,"result":{"id":"r9"}}`))
+				}
+			})
+			defer srv.Close()
+
+			c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+			got, err := c.CanEditDNS(context.Background(), "z1")
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, *got)
+			}
+			require.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
+func TestCanEditDNS_DryRunSkipsProbe(t *testing.T) {
+	var calls []string
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		w.Write([]byte(`{"success":true,"result":{"id":"z1","name":"example.com"}}`))
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL), cloudflare.WithDryRun())
+	_, err := c.CanEditDNS(context.Background(), "z1")
+	require.ErrorIs(t, err, cloudflare.ErrDryRun)
+	require.Equal(t, []string{"GET /zones/z1"}, calls)
+}
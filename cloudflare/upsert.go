@@ -0,0 +1,238 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// MultipleMatchPolicy decides what UpsertDNSRecord does when more than one
+// record shares the desired type and name.
+type MultipleMatchPolicy int
+
+const (
+	// MultipleMatchError fails without changing anything.
+	MultipleMatchError MultipleMatchPolicy = iota
+	// MultipleMatchReplaceAll keeps the first match that already equals the
+	// desired record, or else reconciles the first match, and deletes the others.
+	MultipleMatchReplaceAll
+	// MultipleMatchKeepFirst reconciles the first match and leaves the others untouched.
+	MultipleMatchKeepFirst
+)
+
+// UpsertAction reports what UpsertDNSRecord did.
+type UpsertAction string
+
+const (
+	// UpsertUnchanged means an existing record already matched.
+	UpsertUnchanged UpsertAction = "unchanged"
+	// UpsertCreated means no record existed and one was created.
+	UpsertCreated UpsertAction = "created"
+	// UpsertUpdated means an existing record was updated.
+	UpsertUpdated UpsertAction = "updated"
+)
+
+// UpsertOptions configures UpsertDNSRecord.
+type UpsertOptions struct {
+	// ZoneName expands a record label to its FQDN. When empty the zone is
+	// fetched by id to learn its name, unless the record name is already an
+	// FQDN with existing records.
+	ZoneName string
+	// OnMultiple selects the behavior when several records match type and name.
+	OnMultiple MultipleMatchPolicy
+}
+
+// UpsertResult describes the outcome of UpsertDNSRecord.
+type UpsertResult struct {
+	// Action is what was done.
+	Action UpsertAction
+	// Record is the record as it exists after the call.
+	Record *DNSRecord
+	// Previous is the record before an update; nil on create.
+	Previous *DNSRecord
+	// Deleted lists duplicates removed under MultipleMatchReplaceAll.
+	Deleted []DNSRecord
+}
+
+// UpsertDNSRecord makes the zone contain rec: it resolves rec.Name (label or
+// FQDN) against the zone, looks up existing records by type and name, does
+// nothing when one already matches, updates it when it differs, and creates
+// the record when none exists. Multiple matches are handled per opts.OnMultiple.
+func (c *Client) UpsertDNSRecord(ctx context.Context, zoneID string, rec DNSRecord, opts UpsertOptions) (*UpsertResult, error) {
+	if zoneID == "" {
+		return nil, errors.New("zoneID is required")
+	}
+	rec.Type = strings.ToUpper(rec.Type)
+	if err := ValidateDNSRecord(rec); err != nil {
+		return nil, err
+	}
+	existing, err := c.lookupMatches(ctx, zoneID, &rec, opts.ZoneName)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 1 && opts.OnMultiple == MultipleMatchError {
+		return nil, fmt.Errorf("upsert %s %s: %d matching records exist", rec.Type, rec.Name, len(existing))
+	}
+
+	res := &UpsertResult{}
+	keep := 0
+	if len(existing) == 0 {
+		created, err := c.CreateDNSRecord(ctx, zoneID, rec)
+		if err != nil {
+			return nil, err
+		}
+		res.Action, res.Record = UpsertCreated, created
+	} else {
+		if opts.OnMultiple == MultipleMatchReplaceAll {
+			keep = max(0, slices.IndexFunc(existing, func(r DNSRecord) bool { return RecordMatches(r, rec) }))
+		}
+		current := existing[keep]
+		if RecordMatches(current, rec) {
+			res.Action, res.Record = UpsertUnchanged, &current
+		} else {
+			updated, err := c.UpdateDNSRecord(ctx, zoneID, current.ID, rec)
+			if err != nil {
+				return nil, err
+			}
+			res.Action, res.Record, res.Previous = UpsertUpdated, updated, &current
+		}
+	}
+
+	c.debug(ctx, "upserted record", "fqdn", rec.Name, "type", rec.Type, "action", string(res.Action),
+		"record_id", res.Record.ID, "matches", len(existing))
+
+	if opts.OnMultiple == MultipleMatchReplaceAll {
+		for i, dup := range existing {
+			if i == keep {
+				continue
+			}
+			if err := c.DeleteDNSRecord(ctx, zoneID, dup.ID); err != nil {
+				return res, err
+			}
+			res.Deleted = append(res.Deleted, dup)
+		}
+	}
+	return res, nil
+}
+
+// lookupMatches expands rec.Name to its FQDN and lists the zone's records of
+// that type and name. Without a zone name, a name containing a dot is first
+// looked up as given; the zone is only fetched when that finds nothing, and
+// the lookup is only repeated when the name turns out to be a label.
+func (c *Client) lookupMatches(ctx context.Context, zoneID string, rec *DNSRecord, zoneName string) ([]DNSRecord, error) {
+	if zoneName == "" {
+		name := strings.TrimSuffix(strings.TrimSpace(rec.Name), ".")
+		looked := strings.Contains(name, ".")
+		if looked {
+			existing, err := Collect(c.ListDNSRecords(ctx, zoneID, DNSRecordFilter{Type: rec.Type, Name: name}))
+			if err != nil {
+				return nil, fmt.Errorf("upsert lookup failed: %w", err)
+			}
+			if len(existing) > 0 {
+				rec.Name = name
+				return existing, nil
+			}
+		}
+		zone, err := c.GetZone(ctx, zoneID)
+		if err != nil {
+			return nil, err
+		}
+		zoneName = zone.Name
+		if looked && FQDN(name, zoneName) == name {
+			// The name was already the FQDN and has no records yet.
+			rec.Name = name
+			return nil, nil
+		}
+	}
+	rec.Name = FQDN(rec.Name, zoneName)
+	existing, err := Collect(c.ListDNSRecords(ctx, zoneID, DNSRecordFilter{Type: rec.Type, Name: rec.Name}))
+	if err != nil {
+		return nil, fmt.Errorf("upsert lookup failed: %w", err)
+	}
+	return existing, nil
+}
+
+// FQDN expands name relative to zone. "@", the zone itself and names already
+// ending in the zone are returned as FQDNs; anything else is treated as a
+// label within the zone. Trailing dots are removed.
+func FQDN(name, zone string) string {
+	name = strings.TrimSuffix(strings.TrimSpace(name), ".")
+	zone = strings.TrimSuffix(strings.TrimSpace(zone), ".")
+	if zone == "" {
+		return name
+	}
+	lname, lzone := strings.ToLower(name), strings.ToLower(zone)
+	switch {
+	case name == "" || name == "@" || lname == lzone:
+		return zone
+	case strings.HasSuffix(lname, "."+lzone):
+		return name
+	}
+	return name + "." + zone
+}
+
+// RecordMatches reports whether existing already satisfies desired, comparing
+// the fields desired sets: content (IP- and hostname-aware), data, priority and
+// comment when non-empty, and always TTL (0 counts as automatic) and proxied.
+func RecordMatches(existing, desired DNSRecord) bool {
+	if !strings.EqualFold(existing.Type, desired.Type) {
+		return false
+	}
+	if desired.Content != "" && !contentEqual(desired.Type, existing.Content, desired.Content) {
+		return false
+	}
+	if desired.Data != nil && !dataEqual(existing.Data, desired.Data) {
+		return false
+	}
+	if desired.Priority != nil && (existing.Priority == nil || *existing.Priority != *desired.Priority) {
+		return false
+	}
+	if desired.Comment != "" && existing.Comment != desired.Comment {
+		return false
+	}
+	return normalizeTTL(existing.TTL) == normalizeTTL(desired.TTL) && existing.Proxied == desired.Proxied
+}
+
+func normalizeTTL(ttl int) int {
+	if ttl == 0 {
+		return ttlAuto
+	}
+	return ttl
+}
+
+// contentEqual compares record content with type-aware normalization.
+func contentEqual(typ, a, b string) bool {
+	switch strings.ToUpper(typ) {
+	case RecordTypeA, RecordTypeAAAA:
+		ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+		if ipA != nil && ipB != nil {
+			return ipA.Equal(ipB)
+		}
+	case RecordTypeCNAME, RecordTypeNS, RecordTypePTR, RecordTypeMX:
+		return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+	}
+	return a == b
+}
+
+// dataEqual compares structured data after a JSON round trip so that numeric
+// types decoded from the API (float64) match Go literals (int).
+func dataEqual(a, b map[string]any) bool {
+	na, errA := normalizeJSON(a)
+	nb, errB := normalizeJSON(b)
+	return errA == nil && errB == nil && reflect.DeepEqual(na, nb)
+}
+
+func normalizeJSON(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	err = json.Unmarshal(b, &out)
+	return out, err
+}
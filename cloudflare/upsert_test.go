@@ -0,0 +1,246 @@
+package cloudflare_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDNS is an in-memory stand-in for the zone and dns_records endpoints of a single zone.
+type fakeDNS struct {
+	mu      sync.Mutex
+	zoneID  string
+	zone    string
+	records map[string]cloudflare.DNSRecord
+	nextID  int
+	writes  []string
+	// zoneGets and lists count GET requests for the zone and for its records.
+	zoneGets int
+	lists    int
+}
+
+func newFakeDNS(t *testing.T, zoneID, zone string, recs ...cloudflare.DNSRecord) (*fakeDNS, *httptest.Server) {
+	t.Helper()
+	f := &fakeDNS{zoneID: zoneID, zone: zone, records: map[string]cloudflare.DNSRecord{}}
+	for _, r := range recs {
+		f.add(r)
+	}
+	srv := newTestServer(t, f.serve)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeDNS) add(r cloudflare.DNSRecord) cloudflare.DNSRecord {
+	if r.ID == "" {
+		f.nextID++
+		r.ID = fmt.Sprintf("rec%d", f.nextID)
+	}
+	f.records[r.ID] = r
+	return r
+}
+
+func (f *fakeDNS) sorted() []cloudflare.DNSRecord {
+	out := make([]cloudflare.DNSRecord, 0, len(f.records))
+	for _, r := range f.records {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (f *fakeDNS) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	reply := func(result any) {
+		json.NewEncoder(w).Encode(map[string]any{"success": true, "result": result})
+	}
+	base := "/zones/" + f.zoneID
+	switch {
+	case r.URL.Path == base && r.Method == http.MethodGet:
+		f.zoneGets++
+		reply(cloudflare.Zone{ID: f.zoneID, Name: f.zone})
+	case r.URL.Path == base+"/dns_records" && r.Method == http.MethodGet:
+		f.lists++
+		q := r.URL.Query()
+		var out []cloudflare.DNSRecord
+		for _, rec := range f.sorted() {
+			if (q.Get("type") == "" || q.Get("type") == rec.Type) && (q.Get("name") == "" || strings.EqualFold(q.Get("name"), rec.Name)) {
+				out = append(out, rec)
+			}
+		}
+		reply(out)
+	case r.URL.Path == base+"/dns_records" && r.Method == http.MethodPost:
+		var rec cloudflare.DNSRecord
+		json.NewDecoder(r.Body).Decode(&rec)
+		f.writes = append(f.writes, "create "+rec.Type+" "+rec.Name)
+		reply(f.add(rec))
+	case strings.HasPrefix(r.URL.Path, base+"/dns_records/"):
+		id := strings.TrimPrefix(r.URL.Path, base+"/dns_records/")
+		if _, ok := f.records[id]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"success": false, "errors": []map[string]any{{"code": 81044, "message": "Record does not exist."}}})
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			var rec cloudflare.DNSRecord
+			json.NewDecoder(r.Body).Decode(&rec)
+			rec.ID = id
+			f.writes = append(f.writes, "update "+id)
+			reply(f.add(rec))
+		case http.MethodDelete:
+			delete(f.records, id)
+			f.writes = append(f.writes, "delete "+id)
+			reply(map[string]any{"id": id})
+		default:
+			reply(f.records[id])
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestUpsertDNSRecord_CreateThenNoopThenUpdate(t *testing.T) {
+	f, srv := newFakeDNS(t, "zid", "example.com")
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	ctx := context.Background()
+
+	rec := cloudflare.DNSRecord{Type: "A", Name: "home", Content: "203.0.113.1", TTL: 300}
+	res, err := c.UpsertDNSRecord(ctx, "zid", rec, cloudflare.UpsertOptions{})
+	require.NoError(t, err)
+	require.Equal(t, cloudflare.UpsertCreated, res.Action)
+	require.Equal(t, "home.example.com", res.Record.Name)
+
+	res, err = c.UpsertDNSRecord(ctx, "zid", rec, cloudflare.UpsertOptions{ZoneName: "example.com"})
+	require.NoError(t, err)
+	require.Equal(t, cloudflare.UpsertUnchanged, res.Action)
+
+	rec.TTL = 600
+	res, err = c.UpsertDNSRecord(ctx, "zid", rec, cloudflare.UpsertOptions{ZoneName: "example.com"})
+	require.NoError(t, err)
+	require.Equal(t, cloudflare.UpsertUpdated, res.Action)
+	require.Equal(t, 300, res.Previous.TTL)
+	require.Equal(t, 600, res.Record.TTL)
+
+	require.Equal(t, []string{"create A home.example.com", "update rec1"}, f.writes)
+}
+
+func TestUpsertDNSRecord_MultipleMatches(t *testing.T) {
+	dups := []cloudflare.DNSRecord{
+		{Type: "TXT", Name: "x.example.com", Content: "a", TTL: 1},
+		{Type: "TXT", Name: "x.example.com", Content: "b", TTL: 1},
+		{Type: "TXT", Name: "x.example.com", Content: "c", TTL: 1},
+	}
+	desired := cloudflare.DNSRecord{Type: "TXT", Name: "x.example.com", Content: "a", TTL: 1}
+	opts := cloudflare.UpsertOptions{ZoneName: "example.com"}
+
+	t.Run("error", func(t *testing.T) {
+		f, srv := newFakeDNS(t, "zid", "example.com", dups...)
+		c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+		_, err := c.UpsertDNSRecord(context.Background(), "zid", desired, opts)
+		require.ErrorContains(t, err, "3 matching records")
+		require.Empty(t, f.writes)
+	})
+
+	t.Run("keep first", func(t *testing.T) {
+		f, srv := newFakeDNS(t, "zid", "example.com", dups...)
+		c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+		o := opts
+		o.OnMultiple = cloudflare.MultipleMatchKeepFirst
+		res, err := c.UpsertDNSRecord(context.Background(), "zid", desired, o)
+		require.NoError(t, err)
+		require.Equal(t, cloudflare.UpsertUnchanged, res.Action)
+		require.Empty(t, f.writes)
+	})
+
+	t.Run("replace all", func(t *testing.T) {
+		f, srv := newFakeDNS(t, "zid", "example.com", dups...)
+		c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+		o := opts
+		o.OnMultiple = cloudflare.MultipleMatchReplaceAll
+		res, err := c.UpsertDNSRecord(context.Background(), "zid", desired, o)
+		require.NoError(t, err)
+		require.Equal(t, cloudflare.UpsertUnchanged, res.Action)
+		require.Len(t, res.Deleted, 2)
+		require.Equal(t, []string{"delete rec2", "delete rec3"}, f.writes)
+		require.Len(t, f.records, 1)
+	})
+
+	t.Run("replace all keeps the matching duplicate", func(t *testing.T) {
+		f, srv := newFakeDNS(t, "zid", "example.com", dups...)
+		c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+		o := opts
+		o.OnMultiple = cloudflare.MultipleMatchReplaceAll
+		want := desired
+		want.Content = "b"
+		res, err := c.UpsertDNSRecord(context.Background(), "zid", want, o)
+		require.NoError(t, err)
+		require.Equal(t, cloudflare.UpsertUnchanged, res.Action)
+		require.Equal(t, "rec2", res.Record.ID)
+		require.Equal(t, []string{"delete rec1", "delete rec3"}, f.writes)
+	})
+}
+
+func TestUpsertARecord_IsIdempotent(t *testing.T) {
+	f, srv := newFakeDNS(t, "zid", "example.com")
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+
+	_, changed, err := c.UpsertARecord(context.Background(), "zid", "home", "203.0.113.1", 1, false)
+	require.NoError(t, err)
+	require.True(t, changed)
+	_, changed, err = c.UpsertARecord(context.Background(), "zid", "home.example.com", "203.0.113.1", 1, false)
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Len(t, f.records, 1)
+	// Only the bare label needed the zone name.
+	require.Equal(t, 1, f.zoneGets)
+}
+
+func TestUpsertARecord_CreateByFQDN(t *testing.T) {
+	f, srv := newFakeDNS(t, "zid", "example.com")
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+
+	rec, changed, err := c.UpsertARecord(context.Background(), "zid", "home.example.com.", "203.0.113.1", 1, false)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, "home.example.com", rec.Name)
+	// One lookup and one zone fetch: the FQDN is not looked up twice.
+	require.Equal(t, 1, f.lists)
+	require.Equal(t, 1, f.zoneGets)
+	require.Equal(t, []string{"create A home.example.com"}, f.writes)
+}
+
+func TestFQDN(t *testing.T) {
+	cases := map[string]string{
+		"home":               "home.example.com",
+		"home.example.com":   "home.example.com",
+		"home.example.com.":  "home.example.com",
+		"@":                  "example.com",
+		"example.com":        "example.com",
+		"a.b":                "a.b.example.com",
+		"HOME.Example.COM":   "HOME.Example.COM",
+		"notexample.com.org": "notexample.com.org.example.com",
+	}
+	for in, want := range cases {
+		require.Equal(t, want, cloudflare.FQDN(in, "example.com"), in)
+	}
+}
+
+func TestRecordMatches(t *testing.T) {
+	existing := cloudflare.DNSRecord{Type: "AAAA", Name: "h.example.com", Content: "2001:db8::1", TTL: 1}
+	require.True(t, cloudflare.RecordMatches(existing, cloudflare.DNSRecord{Type: "AAAA", Content: "2001:0db8:0:0::1", TTL: 0}))
+	require.False(t, cloudflare.RecordMatches(existing, cloudflare.DNSRecord{Type: "AAAA", Content: "2001:db8::2"}))
+	require.False(t, cloudflare.RecordMatches(existing, cloudflare.DNSRecord{Type: "AAAA", Content: "2001:db8::1", Proxied: true}))
+
+	srvRec := cloudflare.DNSRecord{Type: "SRV", TTL: 1, Data: map[string]any{"port": float64(5060), "target": "sip.example.com"}}
+	require.True(t, cloudflare.RecordMatches(srvRec, cloudflare.DNSRecord{Type: "SRV", Data: map[string]any{"port": 5060, "target": "sip.example.com"}}))
+}
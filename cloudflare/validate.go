@@ -0,0 +1,133 @@
+package cloudflare
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// TTL bounds accepted by Cloudflare; 1 means automatic.
+const (
+	ttlAuto = 1
+	ttlMin  = 30
+	ttlMax  = 86400
+)
+
+// recordSpec describes the per-type validation rules for a DNS record.
+type recordSpec struct {
+	// proxiable types may set Proxied.
+	proxiable bool
+	// priority types require DNSRecord.Priority.
+	priority bool
+	// dataKeys are the fields required in DNSRecord.Data when Data is used.
+	dataKeys []string
+	// content validates DNSRecord.Content when set; nil accepts any non-empty value.
+	content func(string) error
+	// dataOnly types cannot be expressed through Content alone.
+	dataOnly bool
+}
+
+var recordSpecs = map[string]recordSpec{
+	RecordTypeA:      {proxiable: true, content: validateIPv4},
+	RecordTypeAAAA:   {proxiable: true, content: validateIPv6},
+	RecordTypeCNAME:  {proxiable: true, content: validateHostname},
+	RecordTypeNS:     {content: validateHostname},
+	RecordTypePTR:    {content: validateHostname},
+	RecordTypeMX:     {priority: true, content: validateHostname},
+	RecordTypeTXT:    {},
+	RecordTypeSRV:    {dataKeys: []string{"priority", "weight", "port", "target"}},
+	RecordTypeCAA:    {dataKeys: []string{"flags", "tag", "value"}},
+	RecordTypeHTTPS:  {dataKeys: []string{"priority", "target", "value"}},
+	RecordTypeSVCB:   {dataKeys: []string{"priority", "target", "value"}},
+	RecordTypeTLSA:   {dataKeys: []string{"usage", "selector", "matching_type", "certificate"}},
+	RecordTypeSSHFP:  {dataKeys: []string{"algorithm", "type", "fingerprint"}},
+	RecordTypeNAPTR:  {dataKeys: []string{"order", "preference", "flags", "service", "regex", "replacement"}},
+	RecordTypeURI:    {priority: true, dataKeys: []string{"weight", "target"}, dataOnly: true},
+	RecordTypeDS:     {dataKeys: []string{"key_tag", "algorithm", "digest_type", "digest"}},
+	RecordTypeDNSKEY: {dataKeys: []string{"flags", "protocol", "algorithm", "public_key"}},
+	RecordTypeLOC: {dataKeys: []string{
+		"lat_degrees", "lat_minutes", "lat_seconds", "lat_direction",
+		"long_degrees", "long_minutes", "long_seconds", "long_direction",
+		"altitude", "size", "precision_horz", "precision_vert",
+	}},
+}
+
+// ValidateDNSRecord checks rec against the rules of its record type before it
+// is sent to the API: known type, name, TTL range, proxied eligibility,
+// content format (IPv4 for A, IPv6 for AAAA, hostname for CNAME/NS/PTR/MX),
+// priority where required, and the required keys of the structured data object.
+func ValidateDNSRecord(rec DNSRecord) error {
+	typ := strings.ToUpper(rec.Type)
+	spec, ok := recordSpecs[typ]
+	if !ok {
+		return fmt.Errorf("unsupported record type %q", rec.Type)
+	}
+	if strings.TrimSpace(rec.Name) == "" {
+		return fmt.Errorf("%s record: name is required", typ)
+	}
+	if rec.TTL != 0 && rec.TTL != ttlAuto && (rec.TTL < ttlMin || rec.TTL > ttlMax) {
+		return fmt.Errorf("%s record: ttl must be 1 (auto) or between %d and %d", typ, ttlMin, ttlMax)
+	}
+	if rec.Proxied && !spec.proxiable {
+		return fmt.Errorf("%s record: cannot be proxied", typ)
+	}
+	if spec.priority && rec.Priority == nil {
+		return fmt.Errorf("%s record: priority is required", typ)
+	}
+	if rec.Data != nil {
+		if len(spec.dataKeys) == 0 {
+			return fmt.Errorf("%s record: data is not supported, use content", typ)
+		}
+		for _, k := range spec.dataKeys {
+			if _, ok := rec.Data[k]; !ok {
+				return fmt.Errorf("%s record: data.%s is required", typ, k)
+			}
+		}
+		return nil
+	}
+	if spec.dataOnly {
+		return fmt.Errorf("%s record: data is required", typ)
+	}
+	if rec.Content == "" {
+		if len(spec.dataKeys) > 0 {
+			return fmt.Errorf("%s record: content or data is required", typ)
+		}
+		return fmt.Errorf("%s record: content is required", typ)
+	}
+	if spec.content != nil {
+		if err := spec.content(rec.Content); err != nil {
+			return fmt.Errorf("%s record: %w", typ, err)
+		}
+	}
+	return nil
+}
+
+func validateIPv4(s string) error {
+	ip := net.ParseIP(s)
+	if ip == nil || ip.To4() == nil {
+		return fmt.Errorf("content %q is not an IPv4 address", s)
+	}
+	return nil
+}
+
+func validateIPv6(s string) error {
+	ip := net.ParseIP(s)
+	if ip == nil || ip.To4() != nil {
+		return fmt.Errorf("content %q is not an IPv6 address", s)
+	}
+	return nil
+}
+
+func validateHostname(s string) error {
+	h := strings.TrimSuffix(s, ".")
+	if h == "" || len(h) > 253 {
+		return errors.New("content must be a hostname")
+	}
+	for _, label := range strings.Split(h, ".") {
+		if label == "" || len(label) > 63 || strings.ContainsAny(label, " \t\"") {
+			return fmt.Errorf("content %q is not a valid hostname", s)
+		}
+	}
+	return nil
+}
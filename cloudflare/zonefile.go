@@ -0,0 +1,90 @@
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+)
+
+// maxZoneFileSize bounds the size of an exported zone file read into memory.
+const maxZoneFileSize = 64 << 20
+
+// ZoneImportResult summarizes a BIND zone file import.
+type ZoneImportResult struct {
+	RecordsAdded       int `json:"recs_added"`
+	TotalRecordsParsed int `json:"total_records_parsed"`
+}
+
+// ExportZoneFile downloads the zone's DNS records as a BIND master file.
+// Exports larger than 64 MiB fail rather than being truncated.
+func (c *Client) ExportZoneFile(ctx context.Context, zoneID string) (io.Reader, error) {
+	if zoneID == "" {
+		return nil, errors.New("zoneID is required")
+	}
+	req, err := http.NewRequest(http.MethodGet, c.buildURL("zones/"+zoneID+"/dns_records/export"), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		_, err := decodeResponse[json.RawMessage](req, resp)
+		return nil, fmt.Errorf("export zone file failed: %w", err)
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, io.LimitReader(resp.Body, maxZoneFileSize+1)); err != nil {
+		return nil, err
+	}
+	if buf.Len() > maxZoneFileSize {
+		return nil, fmt.Errorf("export zone file failed: larger than %d MiB", maxZoneFileSize>>20)
+	}
+	return &buf, nil
+}
+
+// ImportZoneFile uploads a BIND master file read from r into the zone. proxied
+// sets the proxied flag of imported records that support proxying.
+func (c *Client) ImportZoneFile(ctx context.Context, zoneID string, r io.Reader, proxied bool) (*ZoneImportResult, error) {
+	if zoneID == "" || r == nil {
+		return nil, errors.New("zoneID and zone file are required")
+	}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", "zone.txt")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return nil, err
+	}
+	if err := mw.WriteField("proxied", strconv.FormatBool(proxied)); err != nil {
+		return nil, err
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.buildURL("zones/"+zoneID+"/dns_records/import"), &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(headerContentType, mw.FormDataContentType())
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	out, err := decodeResponse[ZoneImportResult](req, resp)
+	if err != nil {
+		return nil, fmt.Errorf("import zone file failed: %w", err)
+	}
+	return &out.Result, nil
+}
@@ -0,0 +1,205 @@
+package cloudflare_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleZone = `;; example.com zone
+$ORIGIN example.com.
+$TTL 1h
+@	IN	SOA	ns1.example.com. admin.example.com. (
+		2024010101 ; serial
+		7200       ; refresh
+		3600 1209600 300 )
+@		IN	NS	ns1
+	300	IN	MX	10 mail.example.com.
+www	120	IN	A	203.0.113.10 ; cf_tags=cf-proxied:true
+www		IN	AAAA	2001:db8::10
+blog	IN	300	CNAME	www
+txt		TXT	"v=spf1 include:_spf.example.com -all"
+multi		TXT	( "part one"
+			  "part \"two\"" )
+_sip._tcp	SRV	10 5 5060 sip.example.com.
+@		CAA	0 issue "letsencrypt.org"
+_http._tcp	URI	10 1 "https://example.com/"
+_443._tcp.www	TLSA	3 1 1 0123456789abcdef
+$ORIGIN sub.example.com.
+host		A	198.51.100.7
+`
+
+func TestParseZoneFile(t *testing.T) {
+	recs, err := cloudflare.ParseZoneFile(strings.NewReader(sampleZone), "example.com")
+	require.NoError(t, err)
+	require.Len(t, recs, 12)
+
+	byName := func(typ, name string) cloudflare.DNSRecord {
+		t.Helper()
+		for _, r := range recs {
+			if r.Type == typ && r.Name == name {
+				return r
+			}
+		}
+		t.Fatalf("record %s %s not found", typ, name)
+		return cloudflare.DNSRecord{}
+	}
+
+	ns := byName("NS", "example.com")
+	require.Equal(t, "ns1.example.com", ns.Content)
+	require.Equal(t, 3600, ns.TTL)
+
+	mx := byName("MX", "example.com")
+	require.Equal(t, "mail.example.com", mx.Content)
+	require.Equal(t, uint16(10), *mx.Priority)
+	require.Equal(t, 300, mx.TTL)
+
+	a := byName("A", "www.example.com")
+	require.True(t, a.Proxied)
+	require.Equal(t, 120, a.TTL)
+	require.False(t, byName("AAAA", "www.example.com").Proxied)
+
+	cname := byName("CNAME", "blog.example.com")
+	require.Equal(t, "www.example.com", cname.Content)
+	require.Equal(t, 300, cname.TTL)
+
+	require.Equal(t, "v=spf1 include:_spf.example.com -all", byName("TXT", "txt.example.com").Content)
+	require.Equal(t, `"part one" "part \"two\""`, byName("TXT", "multi.example.com").Content)
+
+	srvRec := byName("SRV", "_sip._tcp.example.com")
+	require.Equal(t, map[string]any{"priority": 10, "weight": 5, "port": 5060, "target": "sip.example.com"}, srvRec.Data)
+
+	caa := byName("CAA", "example.com")
+	require.Equal(t, "letsencrypt.org", caa.Data["value"])
+
+	uri := byName("URI", "_http._tcp.example.com")
+	require.Equal(t, uint16(10), *uri.Priority)
+	require.Equal(t, "https://example.com/", uri.Data["target"])
+
+	require.Equal(t, "3 1 1 0123456789abcdef", byName("TLSA", "_443._tcp.www.example.com").Content)
+	require.Equal(t, "198.51.100.7", byName("A", "host.sub.example.com").Content)
+
+	for _, r := range recs {
+		require.NoError(t, cloudflare.ValidateDNSRecord(r), "%s %s", r.Type, r.Name)
+	}
+}
+
+func TestParseZoneFile_Errors(t *testing.T) {
+	cases := map[string]string{
+		"unbalanced":        "www A (203.0.113.1\n",
+		"unterminated":      "txt TXT \"abc\n",
+		"unsupported type":  "x.example.com. 300 IN HINFO cpu os\n",
+		"bad mx":            "example.com. MX ten mail\n",
+		"include directive": "$INCLUDE other.zone\n",
+		"missing owner":     "  300 IN A 203.0.113.1\n",
+		"escape over 255":   "txt TXT \"a\\300b\"\n",
+	}
+	for name, input := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := cloudflare.ParseZoneFile(strings.NewReader(input), "example.com")
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParseZoneFile_DecimalEscapes(t *testing.T) {
+	recs, err := cloudflare.ParseZoneFile(strings.NewReader(`txt TXT "a\065\255"`+"\n"), "example.com")
+	require.NoError(t, err)
+	require.Equal(t, "aA\xff", recs[0].Content)
+
+	_, err = cloudflare.ParseZoneFile(strings.NewReader("$TTL 300\ntxt TXT \"a\\300b\"\n"), "example.com")
+	require.EqualError(t, err, `line 2: invalid escape \300: exceeds 255`)
+}
+
+func TestWriteZoneFile_RoundTrip(t *testing.T) {
+	recs, err := cloudflare.ParseZoneFile(strings.NewReader(sampleZone), "example.com")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, cloudflare.WriteZoneFile(&buf, "example.com", recs))
+	require.Contains(t, buf.String(), "$ORIGIN example.com.\n")
+	require.Contains(t, buf.String(), "www.example.com.\t120\tIN\tA\t203.0.113.10 ; cf_tags=cf-proxied:true\n")
+
+	again, err := cloudflare.ParseZoneFile(&buf, "example.com")
+	require.NoError(t, err)
+	require.Equal(t, recs, again)
+}
+
+func TestExportZoneFile(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, "/zones/zid/dns_records/export", r.URL.Path)
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "www.example.com.\t1\tIN\tA\t203.0.113.1\n")
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	r, err := c.ExportZoneFile(context.Background(), "zid")
+	require.NoError(t, err)
+	recs, err := cloudflare.ParseZoneFile(r, "example.com")
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	require.Equal(t, 1, recs[0].TTL)
+}
+
+func TestExportZoneFile_TooLarge(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.CopyN(w, zeroReader{}, 64<<20+1)
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	_, err := c.ExportZoneFile(context.Background(), "zid")
+	require.ErrorContains(t, err, "larger than 64 MiB")
+}
+
+// zeroReader is an endless stream of zero bytes.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestExportZoneFile_APIError(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]any{"success": false, "errors": []map[string]any{{"code": 10000, "message": "Authentication error"}}})
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	_, err := c.ExportZoneFile(context.Background(), "zid")
+	require.True(t, cloudflare.IsAuth(err))
+}
+
+func TestImportZoneFile(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/zones/zid/dns_records/import", r.URL.Path)
+		require.True(t, strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data"))
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		require.Equal(t, "true", r.FormValue("proxied"))
+		f, _, err := r.FormFile("file")
+		require.NoError(t, err)
+		b, _ := io.ReadAll(f)
+		require.Contains(t, string(b), "203.0.113.1")
+		json.NewEncoder(w).Encode(map[string]any{"success": true, "result": map[string]any{"recs_added": 1, "total_records_parsed": 1}})
+	})
+	defer srv.Close()
+
+	c := mustClient(t, cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL))
+	res, err := c.ImportZoneFile(context.Background(), "zid", strings.NewReader("www 300 IN A 203.0.113.1\n"), true)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.RecordsAdded)
+	require.Equal(t, 1, res.TotalRecordsParsed)
+}
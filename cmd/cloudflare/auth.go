@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+)
+
+// defaultExpiryWarning is how long before its expiry a token is reported.
+const defaultExpiryWarning = 14 * 24 * time.Hour
+
+// authCommands maps `cloudflare auth <subcommand>` names to their entry points.
+var authCommands = map[string]command{
+	"check": runAuthCheck,
+}
+
+// runAuth dispatches the auth subcommands.
+func runAuth(cf *clientFlags, args []string) error {
+	return dispatch("auth", authCommands, cf, args)
+}
+
+// Statuses of an authCheck.
+const (
+	checkOK   = "ok"
+	checkWarn = "warn"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+// authCheck is the outcome of one preflight check. Its JSON encoding is part
+// of the documented -output json schema.
+type authCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+	// err is the failure of a failed check, classified by exitCode.
+	err error
+}
+
+// authReport is the outcome of the preflight checks of one client and zone.
+type authReport struct {
+	Zone   string                        `json:"zone,omitempty"`
+	Token  *cloudflare.TokenVerification `json:"token,omitempty"`
+	Checks []authCheck                   `json:"checks"`
+	OK     bool                          `json:"ok"`
+}
+
+// err returns the failure of the first failed check, if any.
+func (r *authReport) err() error {
+	for _, c := range r.Checks {
+		if c.Status == checkFail {
+			return c.err
+		}
+	}
+	return nil
+}
+
+func (r *authReport) add(name, status, detail string, err error) {
+	if status == checkFail && err == nil {
+		err = authError{fmt.Errorf("%s: %s", name, detail)}
+	}
+	r.Checks = append(r.Checks, authCheck{Name: name, Status: status, Detail: detail, err: err})
+}
+
+// preflight verifies the client's token and, if zone is set, that the
+// credentials can read the zone and its DNS records and edit them, without
+// changing anything. Tokens expiring within expiryWarning get a warning; the
+// DNS edit check is skipped in dry-run mode when it would need the probe.
+func preflight(ctx context.Context, c *cloudflare.Client, zone string, expiryWarning time.Duration) authReport {
+	r := authReport{Zone: zone}
+	v, err := c.VerifyToken(ctx)
+	now := time.Now()
+	switch {
+	case errors.Is(err, cloudflare.ErrNotAPIToken):
+		r.add("token", checkSkip, "Global API Key credentials cannot be verified", nil)
+	case err != nil:
+		r.add("token", checkFail, err.Error(), err)
+	case v.Status != cloudflare.TokenActive:
+		r.add("token", checkFail, "token is "+v.Status, nil)
+	case v.NotBefore != nil && now.Before(*v.NotBefore):
+		r.add("token", checkFail, "token is not valid before "+v.NotBefore.Format(time.RFC3339), nil)
+	default:
+		r.add("token", checkOK, "active, id "+v.ID, nil)
+	}
+	if v != nil {
+		r.Token = v
+		switch {
+		case v.ExpiresOn == nil:
+			r.add("expiry", checkOK, "never expires", nil)
+		case v.ExpiresOn.Sub(now) < expiryWarning:
+			r.add("expiry", checkWarn, "expires "+expiryString(*v.ExpiresOn, now), nil)
+		default:
+			r.add("expiry", checkOK, "expires "+expiryString(*v.ExpiresOn, now), nil)
+		}
+	}
+
+	switch {
+	case r.err() != nil:
+		r.skipZone("token check failed")
+	case zone == "":
+		r.skipZone("no zone given")
+	default:
+		r.checkZone(ctx, c, zone)
+	}
+	r.OK = r.err() == nil
+	return r
+}
+
+// skipZone adds the zone checks as skipped.
+func (r *authReport) skipZone(detail string) {
+	for _, name := range []string{"zone_read", "dns_read", "dns_edit"} {
+		r.add(name, checkSkip, detail, nil)
+	}
+}
+
+// checkZone adds the zone_read, dns_read and dns_edit checks of zone.
+func (r *authReport) checkZone(ctx context.Context, c *cloudflare.Client, zone string) {
+	zoneID, err := c.FindZoneID(ctx, zone)
+	switch {
+	case errors.Is(err, cloudflare.ErrZoneNotFound):
+		r.add("zone_read", checkFail, "zone not found or not visible to the credentials (needs Zone:Read)", err)
+	case err != nil:
+		r.add("zone_read", checkFail, err.Error(), err)
+	default:
+		r.add("zone_read", checkOK, "zone id "+zoneID, nil)
+	}
+	if err != nil {
+		r.add("dns_read", checkSkip, "zone lookup failed", nil)
+		r.add("dns_edit", checkSkip, "zone lookup failed", nil)
+		return
+	}
+
+	var readErr error
+	for _, err := range c.ListDNSRecords(ctx, zoneID, cloudflare.DNSRecordFilter{PerPage: 1}) {
+		readErr = err
+		break
+	}
+	if readErr != nil {
+		r.add("dns_read", checkFail, readErr.Error(), readErr)
+	} else {
+		r.add("dns_read", checkOK, "can list DNS records", nil)
+	}
+
+	switch edit, err := c.CanEditDNS(ctx, zoneID); {
+	case errors.Is(err, cloudflare.ErrDryRun):
+		r.add("dns_edit", checkSkip, "skipped (dry-run)", nil)
+	case err != nil:
+		r.add("dns_edit", checkFail, err.Error(), err)
+	case !edit.Allowed:
+		r.add("dns_edit", checkFail, "cannot edit DNS records (needs Zone.DNS:Edit)", nil)
+	case edit.Deleted != "":
+		r.add("dns_edit", checkWarn, "can edit DNS records; the probe created record "+edit.Deleted+", which was deleted again", nil)
+	default:
+		r.add("dns_edit", checkOK, "can edit DNS records", nil)
+	}
+}
+
+// expiryString formats the expiry time t relative to now.
+func expiryString(t, now time.Time) string {
+	d := t.Sub(now)
+	switch {
+	case d <= 0:
+		return t.Format(time.RFC3339) + " (expired)"
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%s (in %s)", t.Format(time.RFC3339), d.Round(time.Minute))
+	}
+	return fmt.Sprintf("%s (in %d days)", t.Format(time.RFC3339), int(d/(24*time.Hour)))
+}
+
+// runAuthCheck verifies the credentials and, with -zone, the access the ddns
+// and dns commands need.
+func runAuthCheck(cf *clientFlags, args []string) error {
+	fs := flag.NewFlagSet("cloudflare auth check", flag.ExitOnError)
+	var (
+		zone          = fs.String("zone", envOr("ZONE", ""), "Zone to check read and DNS edit access on")
+		expiryWarning = fs.Duration("expiry-warning", envOrDuration("EXPIRY_WARNING", defaultExpiryWarning), "Warn when the token expires within this long")
+	)
+	cf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+	c, err := cf.newClient()
+	if err != nil {
+		return err
+	}
+	r := preflight(ctx, c, *zone, *expiryWarning)
+	rows := make([][]string, 0, len(r.Checks))
+	for _, check := range r.Checks {
+		rows = append(rows, []string{check.Name, check.Status, check.Detail})
+	}
+	table := func(w io.Writer) error { return writeTable(w, []string{"CHECK", "STATUS", "DETAIL"}, rows) }
+	if err := cf.render(view{value: r, text: table, table: table}); err != nil {
+		return err
+	}
+	if err := r.err(); err != nil {
+		return reportedError{err}
+	}
+	return nil
+}
+
+// preflight runs the auth checks of every record's client and zone once,
+// logging warnings, and returns the first failure.
+func (f *fleet) preflight(ctx context.Context) error {
+	type target struct {
+		client *cloudflare.Client
+		zone   string
+	}
+	seen := make(map[target]bool)
+	for _, u := range f.updaters {
+		t := target{u.client, u.cfg.zone}
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		r := preflight(ctx, u.client, u.cfg.zone, defaultExpiryWarning)
+		for _, check := range r.Checks {
+			level := slog.LevelDebug
+			if check.Status == checkWarn {
+				level = slog.LevelWarn
+			}
+			slog.Log(ctx, level, "preflight", "zone", u.cfg.zone, "check", check.Name, "status", check.Status, "detail", check.Detail)
+		}
+		if err := r.err(); err != nil {
+			return fmt.Errorf("preflight %s: %w", u.cfg.zone, err)
+		}
+	}
+	return nil
+}
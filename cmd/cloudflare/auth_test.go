@@ -0,0 +1,25 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthCheck_DryRunSkipsDNSEditProbe(t *testing.T) {
+	f, srv := newFakeAPI(t)
+	code, stdout, stderr := runTestCLI(t, srv.URL, "auth", "check", "-zone", "example.com", "-dry-run", "-output", "json")
+	require.Equal(t, exitOK, code, stderr)
+
+	var report authReport
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+	require.True(t, report.OK)
+	statuses := make(map[string]string)
+	for _, c := range report.Checks {
+		statuses[c.Name] = c.Status + ": " + c.Detail
+	}
+	require.Equal(t, "ok: can list DNS records", statuses["dns_read"])
+	require.Equal(t, "skip: skipped (dry-run)", statuses["dns_edit"])
+	require.NotContains(t, f.takeCalls(), "POST /zones/zid/dns_records")
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/jsirianni/cloudflare-go/internal/config"
+)
+
+// configCommands maps `cloudflare config <subcommand>` names to their entry points.
+var configCommands = map[string]command{
+	"validate": runConfigValidate,
+}
+
+// runConfig dispatches the config subcommands.
+func runConfig(cf *clientFlags, args []string) error {
+	return dispatch("config", configCommands, cf, args)
+}
+
+// configRecord is a resolved record in the config validate output.
+type configRecord struct {
+	FQDN     string   `json:"fqdn"`
+	Zone     string   `json:"zone"`
+	Profile  string   `json:"profile"`
+	Types    []string `json:"types"`
+	TTL      int      `json:"ttl"`
+	Proxied  bool     `json:"proxied"`
+	IPSource string   `json:"ip_source"`
+}
+
+// runConfigValidate checks a configuration file, including its IP source
+// specs, and lists the records it resolves to without calling Cloudflare.
+func runConfigValidate(cf *clientFlags, args []string) error {
+	fs := flag.NewFlagSet("cloudflare config validate", flag.ExitOnError)
+	var (
+		path     = fs.String("config", envOr("CONFIG", ""), "Configuration file to validate")
+		ipSource = fs.String("ip-source", envOr("IP_SOURCE", "ipify"), "IP source for records that do not set ip_source")
+	)
+	cf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return usagef("config is required")
+	}
+	file, err := config.Load(*path)
+	if err != nil {
+		return usageError{err}
+	}
+	var (
+		errs    []error
+		records = []configRecord{}
+	)
+	for i, rec := range file.Resolved() {
+		src := recordIPSource(rec, *ipSource, 0)
+		if _, err := newIPDiscoverer(src.spec, src.quorum, nil); err != nil {
+			errs = append(errs, fmt.Errorf("records[%d]: %w", i, err))
+			continue
+		}
+		records = append(records, configRecord{
+			FQDN:     cloudflare.FQDN(rec.Name, rec.Zone),
+			Zone:     rec.Zone,
+			Profile:  rec.Profile,
+			Types:    rec.Types,
+			TTL:      *rec.TTL,
+			Proxied:  *rec.Proxied,
+			IPSource: src.spec,
+		})
+	}
+	if err := errors.Join(errs...); err != nil {
+		return usageError{err}
+	}
+	rows := make([][]string, 0, len(records))
+	for _, r := range records {
+		rows = append(rows, []string{r.FQDN, strings.Join(r.Types, ","), ttlString(r.TTL), strconv.FormatBool(r.Proxied), r.Profile, r.IPSource})
+	}
+	return cf.render(view{
+		value: struct {
+			Path     string         `json:"path"`
+			Valid    bool           `json:"valid"`
+			Profiles int            `json:"profiles"`
+			Records  []configRecord `json:"records"`
+		}{*path, true, len(file.Profiles), records},
+		text: func(w io.Writer) error {
+			for _, r := range rows {
+				fmt.Fprintf(w, "%s\t%s\tttl=%s proxied=%s profile=%s ip-source=%s\n", r[0], r[1], r[2], r[3], r[4], r[5])
+			}
+			fmt.Fprintf(w, "%s: OK (%d records, %d profiles)\n", *path, len(records), len(file.Profiles))
+			return nil
+		},
+		table: func(w io.Writer) error {
+			return writeTable(w, []string{"FQDN", "TYPES", "TTL", "PROXIED", "PROFILE", "IP_SOURCE"}, rows)
+		},
+	})
+}
+
+// ipSourceKey identifies a discoverer so records with the same IP source
+// share it.
+type ipSourceKey struct {
+	spec   string
+	quorum int
+}
+
+// recordIPSource returns the record's IP source, falling back to the
+// -ip-source and -ip-quorum flags when the record does not set one.
+func recordIPSource(rec config.Record, spec string, quorum int) ipSourceKey {
+	if rec.IPSource != "" {
+		return ipSourceKey{rec.IPSource, *rec.IPQuorum}
+	}
+	return ipSourceKey{spec, quorum}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jsirianni/cloudflare-go/internal/netutil"
+)
+
+// maxDaemonBackoff caps the wait between failed syncs in daemon mode.
+const maxDaemonBackoff = time.Hour
+
+// runDaemon re-syncs every cfg.interval until SIGINT or SIGTERM. Each cycle is
+// bounded by -timeout. The zone IDs and published addresses are cached by f, so
+// Cloudflare is only called when an address changes and on the periodic
+// forced re-verify. Failed cycles back off exponentially. With
+// -watch-interface, interface changes trigger a cycle right away.
+func runDaemon(cfg *ddnsConfig, cf *clientFlags, f *fleet) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = withSignalCancel(ctx, cancel)
+
+	if cfg.metrics != nil {
+		if err := cfg.metrics.serve(ctx, cfg.listen); err != nil {
+			return err
+		}
+	}
+
+	var changes <-chan struct{}
+	if cfg.watchInterface != "" {
+		iface := cfg.watchInterface
+		if iface == "any" {
+			iface = ""
+		}
+		events, err := netutil.WatchAddressChanges(ctx, iface)
+		if err != nil {
+			return fmt.Errorf("watch %s: %w", cfg.watchInterface, err)
+		}
+		changes = netutil.Debounce(ctx, events, cfg.debounce)
+	}
+
+	var (
+		failures   int
+		verifiedAt time.Time
+	)
+	for {
+		force := cfg.verifyInterval <= 0 || time.Since(verifiedAt) >= cfg.verifyInterval
+		cycleCtx, cycleCancel := context.WithTimeout(ctx, cf.timeout)
+		err := f.sync(cycleCtx, force)
+		cycleCancel()
+		if ctx.Err() != nil {
+			slog.Info("shutting down")
+			return nil
+		}
+
+		wait := cfg.interval
+		if err != nil {
+			failures++
+			wait = daemonBackoff(cfg.interval, failures)
+			slog.Warn("sync failed", "failures", failures, "error", err, "retry_in", wait)
+		} else {
+			failures = 0
+			if force {
+				verifiedAt = time.Now()
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			slog.Info("shutting down")
+			return nil
+		case _, ok := <-changes:
+			timer.Stop()
+			if !ok {
+				changes = nil
+				if ctx.Err() == nil {
+					slog.Warn("stopped watching interface; falling back to polling", "interface", cfg.watchInterface)
+				}
+				continue
+			}
+			slog.Info("interface changed; re-checking", "interface", cfg.watchInterface)
+		case <-timer.C:
+		}
+	}
+}
+
+// daemonBackoff doubles the interval for each consecutive failure, capped at
+// maxDaemonBackoff (or the interval itself if that is longer).
+func daemonBackoff(interval time.Duration, failures int) time.Duration {
+	limit := max(interval, maxDaemonBackoff)
+	wait := interval
+	for i := 1; i < failures && wait < limit; i++ {
+		wait *= 2
+	}
+	return min(wait, limit)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDaemonBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		failures int
+		want     time.Duration
+	}{
+		{name: "first failure", interval: time.Minute, failures: 1, want: time.Minute},
+		{name: "doubles", interval: time.Minute, failures: 2, want: 2 * time.Minute},
+		{name: "keeps doubling", interval: time.Minute, failures: 4, want: 8 * time.Minute},
+		{name: "capped", interval: time.Minute, failures: 10, want: maxDaemonBackoff},
+		{name: "no overflow", interval: time.Minute, failures: 1000, want: maxDaemonBackoff},
+		{name: "interval above cap", interval: 2 * maxDaemonBackoff, failures: 3, want: 2 * maxDaemonBackoff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, daemonBackoff(tt.interval, tt.failures))
+		})
+	}
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/jsirianni/cloudflare-go/internal/netutil"
+)
+
+// ddnsConfig holds the settings of the dynamic DNS flow.
+type ddnsConfig struct {
+	zone    string
+	name    string
+	ttl     int
+	proxied bool
+	ipv4    bool
+	ipv6    bool
+	// ipSource lists the public IP sources (see ipSourceHelp) and ipQuorum
+	// how many of them must agree.
+	ipSource string
+	ipQuorum int
+	// ipv6Interface selects local interface discovery for IPv6 when set;
+	// "any" scans every interface. Empty uses ipSource.
+	ipv6Interface string
+	// deleteAAAA removes the AAAA record when the host has no IPv6 address.
+	deleteAAAA bool
+	// daemon keeps running and re-syncs every interval, forcing a full check
+	// against Cloudflare every verifyInterval.
+	daemon         bool
+	interval       time.Duration
+	verifyInterval time.Duration
+	// watchInterface triggers an immediate sync on netlink address, route or
+	// link changes of this interface ("any" for all), once no further change
+	// has arrived for debounce. Polling continues as a fallback.
+	watchInterface string
+	debounce       time.Duration
+	// configFile names a configuration file declaring many records; it
+	// replaces -zone/-name and the credential flags, and concurrency bounds
+	// how many records are synced at once.
+	configFile  string
+	concurrency int
+	// listen is the address daemon mode serves /metrics, /healthz and
+	// /readyz on; /healthz fails once syncs have failed for unhealthyAfter.
+	// metrics is set when listen is.
+	listen         string
+	unhealthyAfter time.Duration
+	metrics        *ddnsMetrics
+	// preflight verifies the token and the zone and DNS edit access of every
+	// record before the first sync.
+	preflight bool
+}
+
+// runDDNS implements the ddns command: it points NAME.ZONE at the current
+// public IP.
+func runDDNS(cf *clientFlags, args []string) error {
+	fs := flag.NewFlagSet("cloudflare ddns", flag.ExitOnError)
+	cfg := &ddnsConfig{}
+	fs.StringVar(&cfg.zone, "zone", envOr("ZONE", ""), "Cloudflare zone (apex domain)")
+	fs.StringVar(&cfg.name, "name", envOr("NAME", ""), "Record name/label within the zone")
+	fs.IntVar(&cfg.ttl, "ttl", envOrInt("TTL", 1), "TTL in seconds (1=auto)")
+	fs.BoolVar(&cfg.proxied, "proxied", envOrBool("PROXIED", false), "Whether the record is proxied")
+	fs.BoolVar(&cfg.ipv4, "ipv4", envOrBool("IPV4", true), "Keep the A record in sync with the public IPv4 address")
+	fs.BoolVar(&cfg.ipv6, "ipv6", envOrBool("IPV6", false), "Keep the AAAA record in sync with the public IPv6 address")
+	fs.StringVar(&cfg.ipSource, "ip-source", envOr("IP_SOURCE", "ipify"), ipSourceHelp)
+	fs.IntVar(&cfg.ipQuorum, "ip-quorum", envOrInt("IP_QUORUM", 0), "Number of -ip-source sources that must agree (0 = majority)")
+	fs.StringVar(&cfg.ipv6Interface, "ipv6-interface", envOr("IPV6_INTERFACE", ""), "Take the IPv6 address from this local interface (\"any\" for all) instead of -ip-source")
+	fs.BoolVar(&cfg.deleteAAAA, "delete-aaaa", envOrBool("DELETE_AAAA", false), "Delete the AAAA record when no IPv6 address is available")
+	fs.BoolVar(&cfg.daemon, "daemon", envOrBool("DAEMON", false), "Keep running and re-check the public IP every -interval")
+	fs.DurationVar(&cfg.interval, "interval", envOrDuration("INTERVAL", 5*time.Minute), "Polling interval in daemon mode")
+	fs.DurationVar(&cfg.verifyInterval, "verify-interval", envOrDuration("VERIFY_INTERVAL", time.Hour), "How often daemon mode re-reads the records from Cloudflare even if the IP is unchanged, to catch out-of-band edits")
+	fs.StringVar(&cfg.watchInterface, "watch-interface", envOr("WATCH_INTERFACE", ""), "In daemon mode, re-check immediately when addresses or routes of this interface change (\"any\" for all; Linux only)")
+	fs.DurationVar(&cfg.debounce, "debounce", envOrDuration("DEBOUNCE", 5*time.Second), "Quiet period after the last interface change before re-checking")
+	fs.StringVar(&cfg.configFile, "config", envOr("CONFIG", ""), "Configuration file declaring profiles and records to sync (replaces -zone, -name and credential flags)")
+	fs.IntVar(&cfg.concurrency, "concurrency", envOrInt("CONCURRENCY", 4), "Maximum number of -config records synced at once")
+	fs.StringVar(&cfg.listen, "listen", envOr("LISTEN", ""), "In daemon mode, serve Prometheus /metrics, /healthz and /readyz on this address (e.g. :9100)")
+	fs.DurationVar(&cfg.unhealthyAfter, "unhealthy-after", envOrDuration("UNHEALTHY_AFTER", 15*time.Minute), "How long syncs may keep failing before /healthz and /readyz report unhealthy")
+	fs.BoolVar(&cfg.preflight, "preflight", envOrBool("PREFLIGHT", false), "Verify the token and the zone read and DNS edit access of every record before syncing")
+	cf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return run(cfg, cf)
+}
+
+func run(cfg *ddnsConfig, cf *clientFlags) error {
+	cf.logger()
+	if cfg.daemon && cfg.interval <= 0 {
+		return usagef("interval must be > 0")
+	}
+	if cfg.watchInterface != "" && !cfg.daemon {
+		return usagef("watch-interface requires -daemon")
+	}
+	if cfg.listen != "" {
+		if !cfg.daemon {
+			return usagef("listen requires -daemon")
+		}
+		if cfg.unhealthyAfter <= 0 {
+			return usagef("unhealthy-after must be > 0")
+		}
+		cfg.metrics = newDDNSMetrics(cfg.unhealthyAfter)
+		cf.meter = cfg.metrics
+	}
+	var (
+		f   *fleet
+		err error
+	)
+	if cfg.configFile != "" {
+		f, err = newFleet(cfg, cf)
+	} else {
+		f, err = newSingleRecordFleet(cfg, cf)
+	}
+	if err != nil {
+		return err
+	}
+	if cfg.preflight {
+		ctx, cancel := cf.context()
+		err := f.preflight(ctx)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	if cfg.daemon {
+		return runDaemon(cfg, cf, f)
+	}
+
+	// Context with cancel on interrupt and deadline
+	ctx, cancel := cf.context()
+	defer cancel()
+	return f.sync(ctx, true)
+}
+
+// newSingleRecordFleet syncs the record given by -zone and -name.
+func newSingleRecordFleet(cfg *ddnsConfig, cf *clientFlags) (*fleet, error) {
+	if err := validateInputs(cfg.zone, cfg.name, cfg.ttl, cf); err != nil {
+		return nil, err
+	}
+	discoverer, err := newIPDiscoverer(cfg.ipSource, cfg.ipQuorum, cfg.metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	// Construct client
+	c, err := cf.newClient()
+	if err != nil {
+		return nil, err
+	}
+	u, err := newDDNSUpdater(cfg, c, discoverer)
+	if err != nil {
+		return nil, err
+	}
+	u.dryRun = cf.dryRun
+	return &fleet{cf: cf, updaters: []*ddnsUpdater{u}, concurrency: 1, metrics: cfg.metrics}, nil
+}
+
+// recordResult reports the outcome of publishing one record type. Its JSON
+// encoding is part of the documented -output json schema.
+type recordResult struct {
+	FQDN     string `json:"fqdn"`
+	Type     string `json:"type"`
+	Old      string `json:"old"`
+	New      string `json:"new"`
+	Action   string `json:"action"`
+	RecordID string `json:"record_id"`
+	// DurationMS is how long publishing this record took.
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Actions reported in recordResult, besides the cloudflare.UpsertAction values.
+const (
+	actionDeleted = "deleted"
+	actionFailed  = "failed"
+	// actionSkipped reports an AAAA record left alone because the host has
+	// no IPv6 address and -delete-aaaa is off.
+	actionSkipped = "skipped"
+)
+
+// describe renders the result as a text line; dryRun phrases changes as
+// planned rather than done.
+func (r recordResult) describe(dryRun bool) string {
+	switch r.Action {
+	case string(cloudflare.UpsertUnchanged):
+		return fmt.Sprintf("No change: %s already points to %s", r.FQDN, r.New)
+	case string(cloudflare.UpsertUpdated):
+		if dryRun {
+			return fmt.Sprintf("Would update %s %s: %s -> %s", r.Type, r.FQDN, r.Old, r.New)
+		}
+		return fmt.Sprintf("Updated %s %s -> %s", r.Type, r.FQDN, r.New)
+	case string(cloudflare.UpsertCreated):
+		if dryRun {
+			return fmt.Sprintf("Would create %s %s -> %s", r.Type, r.FQDN, r.New)
+		}
+		return fmt.Sprintf("Created %s %s -> %s", r.Type, r.FQDN, r.New)
+	case actionDeleted:
+		if dryRun {
+			return fmt.Sprintf("Would delete %s %s (%s)", r.Type, r.FQDN, r.Old)
+		}
+		return fmt.Sprintf("Deleted %s %s (%s)", r.Type, r.FQDN, r.Old)
+	case actionFailed:
+		return fmt.Sprintf("Failed %s %s: %s", r.Type, r.FQDN, r.Error)
+	case actionSkipped:
+		return fmt.Sprintf("Skipped %s %s: no IPv6 address", r.Type, r.FQDN)
+	}
+	return fmt.Sprintf("%s %s %s", r.Action, r.Type, r.FQDN)
+}
+
+// ddnsUpdater publishes the discovered addresses and remembers what it last
+// confirmed in Cloudflare, so repeated syncs can skip unchanged addresses.
+type ddnsUpdater struct {
+	cfg        *ddnsConfig
+	client     *cloudflare.Client
+	discoverer netutil.IPDiscoverer
+	fqdn       string
+	// zoneID caches the zone lookup.
+	zoneID string
+	// published maps record type to the address last confirmed in
+	// Cloudflare; an empty value records a deleted AAAA record.
+	published map[string]string
+	// noIPv6 is set once a missing IPv6 address has been reported, so
+	// daemon rounds do not repeat it until IPv6 has come back and gone again.
+	noIPv6 bool
+	// dryRun leaves published alone, since nothing was actually published,
+	// so every daemon round plans the pending changes again.
+	dryRun bool
+}
+
+func newDDNSUpdater(cfg *ddnsConfig, c *cloudflare.Client, discoverer netutil.IPDiscoverer) (*ddnsUpdater, error) {
+	if !cfg.ipv4 && !cfg.ipv6 {
+		return nil, usagef("at least one of -ipv4 and -ipv6 must be enabled")
+	}
+	return &ddnsUpdater{
+		cfg:        cfg,
+		client:     c,
+		discoverer: discoverer,
+		fqdn:       cloudflare.FQDN(cfg.name, cfg.zone),
+		published:  make(map[string]string),
+	}, nil
+}
+
+// update discovers the current addresses and points the records at them.
+// Unless force is set, record types whose address matches the last published
+// one are not sent to Cloudflare at all. Every failure is also reported as a
+// failed result: for each configured type when discovery fails, else for the
+// type whose update failed.
+func (u *ddnsUpdater) update(ctx context.Context, force bool) ([]recordResult, error) {
+	var results []recordResult
+	fail := func(err error, types ...string) ([]recordResult, error) {
+		for _, typ := range types {
+			results = append(results, recordResult{FQDN: u.fqdn, Type: typ, Action: actionFailed, Error: err.Error()})
+		}
+		return results, err
+	}
+	var types []string
+	if u.cfg.ipv4 {
+		types = append(types, cloudflare.RecordTypeA)
+	}
+	if u.cfg.ipv6 {
+		types = append(types, cloudflare.RecordTypeAAAA)
+	}
+
+	// Discover IPs before touching the zone so a discovery failure changes nothing
+	want := make(map[string]string, 2)
+	if u.cfg.ipv4 {
+		ip, err := u.discoverer.DiscoverIP(ctx, netutil.IPv4)
+		if err != nil {
+			return fail(discoveryError{fmt.Errorf("could not determine WAN IP: %w", err)}, types...)
+		}
+		slog.DebugContext(ctx, "discovered address", "fqdn", u.fqdn, "family", "ipv4", "source", u.discoverer.Name(), "ip", ip)
+		want[cloudflare.RecordTypeA] = ip
+	}
+	var skipAAAA bool
+	if u.cfg.ipv6 {
+		ip, err := discoverIPv6(ctx, u.cfg.ipv6Interface, u.discoverer)
+		switch {
+		case errors.Is(err, netutil.ErrNoIPv6) && u.cfg.deleteAAAA:
+			slog.DebugContext(ctx, "no IPv6 address", "fqdn", u.fqdn, "family", "ipv6", "delete_aaaa", true)
+			want[cloudflare.RecordTypeAAAA] = ""
+		case errors.Is(err, netutil.ErrNoIPv6):
+			// Leave the AAAA record alone rather than failing the sync of the
+			// other records.
+			if !u.noIPv6 || force {
+				slog.WarnContext(ctx, "no IPv6 address; leaving AAAA record unchanged", "fqdn", u.fqdn, "family", "ipv6")
+				skipAAAA = true
+			}
+			u.noIPv6 = true
+		case err != nil:
+			return fail(discoveryError{fmt.Errorf("could not determine WAN IPv6: %w", err)}, types...)
+		default:
+			slog.DebugContext(ctx, "discovered address", "fqdn", u.fqdn, "family", "ipv6", "source", ipv6SourceName(u.cfg.ipv6Interface, u.discoverer), "ip", ip)
+			want[cloudflare.RecordTypeAAAA] = ip
+			u.noIPv6 = false
+		}
+	}
+
+	for _, typ := range types {
+		ip, ok := want[typ]
+		if !ok {
+			if typ == cloudflare.RecordTypeAAAA && skipAAAA {
+				results = append(results, recordResult{FQDN: u.fqdn, Type: typ, Action: actionSkipped})
+			}
+			continue
+		}
+		if last, seen := u.published[typ]; seen && last == ip && !force {
+			slog.DebugContext(ctx, "address unchanged since last sync; skipping", "fqdn", u.fqdn, "type", typ, "ip", ip)
+			continue
+		}
+		start := time.Now()
+		res, err := u.publish(ctx, typ, ip)
+		for i := range res {
+			res[i].DurationMS = time.Since(start).Milliseconds()
+		}
+		results = append(results, res...)
+		for _, r := range res {
+			slog.DebugContext(ctx, "record synced", "fqdn", r.FQDN, "type", r.Type, "action", r.Action,
+				"old", r.Old, "new", r.New, "record_id", r.RecordID, "duration", time.Since(start))
+		}
+		if err != nil {
+			slog.DebugContext(ctx, "record sync failed", "fqdn", u.fqdn, "type", typ, "ip", ip, "error", err)
+			// Forget what we know so the next sync re-reads Cloudflare.
+			delete(u.published, typ)
+			if cloudflare.IsNotFound(err) {
+				u.zoneID = ""
+			}
+			return fail(err, typ)
+		}
+		if !u.dryRun {
+			u.published[typ] = ip
+		}
+	}
+	return results, nil
+}
+
+// publish points the typ record at ip, or deletes it when ip is empty.
+func (u *ddnsUpdater) publish(ctx context.Context, typ, ip string) ([]recordResult, error) {
+	// Resolve zone ID
+	if u.zoneID == "" {
+		zoneID, err := u.client.FindZoneID(ctx, u.cfg.zone)
+		if err != nil {
+			return nil, err
+		}
+		slog.DebugContext(ctx, "resolved zone", "zone", u.cfg.zone, "zone_id", zoneID)
+		u.zoneID = zoneID
+	}
+	if ip == "" {
+		return deleteRecords(ctx, u.client, u.zoneID, typ, u.fqdn)
+	}
+	res, err := syncRecord(ctx, u.client, u.zoneID, u.cfg, typ, u.fqdn, ip)
+	if err != nil {
+		return nil, err
+	}
+	return []recordResult{res}, nil
+}
+
+// discoverIPv6 finds the public IPv6 address from a local interface or, when
+// iface is empty, from the configured sources.
+func discoverIPv6(ctx context.Context, iface string, d netutil.IPDiscoverer) (string, error) {
+	switch iface {
+	case "":
+	case "any":
+		d = netutil.NewInterfaceDiscoverer("")
+	default:
+		d = netutil.NewInterfaceDiscoverer(iface)
+	}
+	return d.DiscoverIP(ctx, netutil.IPv6)
+}
+
+// ipv6SourceName names the source discoverIPv6 uses.
+func ipv6SourceName(iface string, d netutil.IPDiscoverer) string {
+	if iface == "" {
+		return d.Name()
+	}
+	return "interface:" + iface
+}
+
+// syncRecord points the typ record of fqdn at ip and reports what changed.
+func syncRecord(ctx context.Context, c *cloudflare.Client, zoneID string, cfg *ddnsConfig, typ, fqdn, ip string) (recordResult, error) {
+	payload := cloudflare.DNSRecord{Type: typ, Name: fqdn, Content: ip, TTL: cfg.ttl, Proxied: cfg.proxied}
+	res, err := c.UpsertDNSRecord(ctx, zoneID, payload, cloudflare.UpsertOptions{ZoneName: cfg.zone})
+	if err != nil {
+		return recordResult{}, err
+	}
+	out := recordResult{FQDN: fqdn, Type: typ, New: ip, Action: string(res.Action), RecordID: res.Record.ID}
+	switch {
+	case res.Previous != nil:
+		out.Old = res.Previous.Content
+	case res.Action == cloudflare.UpsertUnchanged:
+		out.Old = res.Record.Content
+	}
+	return out, nil
+}
+
+// deleteRecords removes every typ record named fqdn.
+func deleteRecords(ctx context.Context, c *cloudflare.Client, zoneID, typ, fqdn string) ([]recordResult, error) {
+	existing, err := cloudflare.Collect(c.ListDNSRecords(ctx, zoneID, cloudflare.DNSRecordFilter{Type: typ, Name: fqdn}))
+	if err != nil {
+		return nil, err
+	}
+	var results []recordResult
+	for _, rec := range existing {
+		if err := c.DeleteDNSRecord(ctx, zoneID, rec.ID); err != nil {
+			return results, err
+		}
+		results = append(results, recordResult{FQDN: fqdn, Type: typ, Old: rec.Content, Action: actionDeleted, RecordID: rec.ID})
+	}
+	return results, nil
+}
+
+func validateInputs(zone, name string, ttl int, cf *clientFlags) error {
+	if strings.TrimSpace(zone) == "" {
+		return usagef("zone is required")
+	}
+	if strings.TrimSpace(name) == "" {
+		return usagef("name is required")
+	}
+	if ttl < 0 {
+		return usagef("ttl must be >= 0 (1 for auto)")
+	}
+	return cf.validate()
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/jsirianni/cloudflare-go/internal/netutil"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPI is an in-memory stand-in for the token verification, zone and
+// dns_records endpoints of the zone example.com (id zid).
+type fakeAPI struct {
+	mu      sync.Mutex
+	records map[string]cloudflare.DNSRecord
+	nextID  int
+	// calls logs every request as "METHOD PATH".
+	calls []string
+	// gone makes every endpoint of the zone answer 404, as if the zone had
+	// been deleted.
+	gone bool
+}
+
+func newFakeAPI(t *testing.T, recs ...cloudflare.DNSRecord) (*fakeAPI, *httptest.Server) {
+	t.Helper()
+	f := &fakeAPI{records: map[string]cloudflare.DNSRecord{}}
+	for _, r := range recs {
+		f.add(r)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeAPI) add(r cloudflare.DNSRecord) cloudflare.DNSRecord {
+	if r.ID == "" {
+		f.nextID++
+		r.ID = fmt.Sprintf("rec%d", f.nextID)
+	}
+	f.records[r.ID] = r
+	return r
+}
+
+// takeCalls returns the requests logged so far and clears the log.
+func (f *fakeAPI) takeCalls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls := f.calls
+	f.calls = nil
+	return calls
+}
+
+func (f *fakeAPI) setGone(gone bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gone = gone
+}
+
+func (f *fakeAPI) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, r.Method+" "+r.URL.Path)
+	reply := func(result any) {
+		json.NewEncoder(w).Encode(map[string]any{"success": true, "result": result})
+	}
+	notFound := func(code int, msg string) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]any{"success": false, "errors": []map[string]any{{"code": code, "message": msg}}})
+	}
+	const base = "/zones/zid"
+	switch {
+	case r.URL.Path == "/user/tokens/verify":
+		reply(cloudflare.TokenVerification{ID: "tok1", Status: cloudflare.TokenActive})
+	case r.URL.Path == "/zones":
+		zones := []cloudflare.Zone{}
+		if name := r.URL.Query().Get("name"); !f.gone && (name == "" || name == "example.com") {
+			zones = append(zones, cloudflare.Zone{ID: "zid", Name: "example.com"})
+		}
+		reply(zones)
+	case f.gone && strings.HasPrefix(r.URL.Path, base):
+		notFound(7003, "Could not route to /zones/zid, perhaps your object identifier is invalid?")
+	case r.URL.Path == base:
+		reply(cloudflare.Zone{ID: "zid", Name: "example.com"})
+	case r.URL.Path == base+"/dns_records" && r.Method == http.MethodGet:
+		q := r.URL.Query()
+		out := []cloudflare.DNSRecord{}
+		ids := make([]string, 0, len(f.records))
+		for id := range f.records {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			rec := f.records[id]
+			if (q.Get("type") == "" || q.Get("type") == rec.Type) && (q.Get("name") == "" || strings.EqualFold(q.Get("name"), rec.Name)) {
+				out = append(out, rec)
+			}
+		}
+		reply(out)
+	case r.URL.Path == base+"/dns_records" && r.Method == http.MethodPost:
+		var rec cloudflare.DNSRecord
+		json.NewDecoder(r.Body).Decode(&rec)
+		rec.Name = cloudflare.FQDN(rec.Name, "example.com")
+		reply(f.add(rec))
+	case strings.HasPrefix(r.URL.Path, base+"/dns_records/"):
+		id := strings.TrimPrefix(r.URL.Path, base+"/dns_records/")
+		cur, ok := f.records[id]
+		if !ok {
+			notFound(81044, "Record does not exist.")
+			return
+		}
+		switch r.Method {
+		case http.MethodPut, http.MethodPatch:
+			rec := cur
+			rec.Data = maps.Clone(cur.Data)
+			if r.Method == http.MethodPut {
+				rec = cloudflare.DNSRecord{}
+			}
+			json.NewDecoder(r.Body).Decode(&rec)
+			rec.ID = id
+			reply(f.add(rec))
+		case http.MethodDelete:
+			delete(f.records, id)
+			reply(map[string]any{"id": id})
+		default:
+			reply(cur)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// staticDiscoverer reports fixed addresses; a missing IPv6 address is
+// reported as netutil.ErrNoIPv6.
+type staticDiscoverer map[netutil.Family]string
+
+func (staticDiscoverer) Name() string { return "static" }
+
+func (d staticDiscoverer) DiscoverIP(_ context.Context, family netutil.Family) (string, error) {
+	if ip, ok := d[family]; ok {
+		return ip, nil
+	}
+	if family == netutil.IPv6 {
+		return "", netutil.ErrNoIPv6
+	}
+	return "", fmt.Errorf("no %s address", family)
+}
+
+func newTestUpdater(t *testing.T, url string, cfg *ddnsConfig, d staticDiscoverer) *ddnsUpdater {
+	t.Helper()
+	c, err := cloudflare.New(cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(url))
+	require.NoError(t, err)
+	u, err := newDDNSUpdater(cfg, c, d)
+	require.NoError(t, err)
+	return u
+}
+
+func actions(results []recordResult) []string {
+	out := make([]string, 0, len(results))
+	for _, r := range results {
+		out = append(out, r.Type+" "+r.Action)
+	}
+	return out
+}
+
+func TestDDNSUpdater_SkipsUnchangedAddresses(t *testing.T) {
+	f, srv := newFakeAPI(t)
+	cfg := &ddnsConfig{zone: "example.com", name: "home", ttl: 1, ipv4: true, ipv6: true}
+	d := staticDiscoverer{netutil.IPv4: "203.0.113.1", netutil.IPv6: "2001:db8::1"}
+	u := newTestUpdater(t, srv.URL, cfg, d)
+	ctx := context.Background()
+
+	res, err := u.update(ctx, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"A created", "AAAA created"}, actions(res))
+	f.takeCalls()
+
+	// Nothing changed: Cloudflare is not asked at all.
+	res, err = u.update(ctx, false)
+	require.NoError(t, err)
+	require.Empty(t, res)
+	require.Empty(t, f.takeCalls())
+
+	// Only the changed address is synced.
+	d[netutil.IPv4] = "203.0.113.2"
+	res, err = u.update(ctx, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"A updated"}, actions(res))
+	require.Equal(t, "203.0.113.1", res[0].Old)
+	require.Equal(t, []string{"GET /zones/zid/dns_records", "PUT /zones/zid/dns_records/rec1"}, f.takeCalls())
+
+	// force re-reads every record.
+	res, err = u.update(ctx, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"A unchanged", "AAAA unchanged"}, actions(res))
+	require.Len(t, f.takeCalls(), 2)
+}
+
+func TestDDNSUpdater_NotFoundClearsCache(t *testing.T) {
+	f, srv := newFakeAPI(t)
+	cfg := &ddnsConfig{zone: "example.com", name: "home", ttl: 1, ipv4: true}
+	d := staticDiscoverer{netutil.IPv4: "203.0.113.1"}
+	u := newTestUpdater(t, srv.URL, cfg, d)
+	ctx := context.Background()
+
+	_, err := u.update(ctx, false)
+	require.NoError(t, err)
+	require.Equal(t, "zid", u.zoneID)
+	require.Equal(t, "203.0.113.1", u.published[cloudflare.RecordTypeA])
+
+	f.setGone(true)
+	d[netutil.IPv4] = "203.0.113.2"
+	res, err := u.update(ctx, false)
+	require.True(t, cloudflare.IsNotFound(err))
+	require.Equal(t, []string{"A failed"}, actions(res))
+	require.Empty(t, u.zoneID)
+	require.NotContains(t, u.published, cloudflare.RecordTypeA)
+
+	// The next sync looks the zone up again and re-reads the record, even
+	// though the address is back to the one published before.
+	f.setGone(false)
+	f.takeCalls()
+	d[netutil.IPv4] = "203.0.113.1"
+	res, err = u.update(ctx, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"A unchanged"}, actions(res))
+	require.Equal(t, []string{"GET /zones", "GET /zones/zid/dns_records"}, f.takeCalls())
+}
+
+func TestDDNSUpdater_MissingIPv6IsSkipped(t *testing.T) {
+	f, srv := newFakeAPI(t, cloudflare.DNSRecord{Type: "AAAA", Name: "home.example.com", Content: "2001:db8::1", TTL: 1})
+	cfg := &ddnsConfig{zone: "example.com", name: "home", ttl: 1, ipv4: true, ipv6: true}
+	u := newTestUpdater(t, srv.URL, cfg, staticDiscoverer{netutil.IPv4: "203.0.113.1"})
+
+	res, err := u.update(context.Background(), false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"A created", "AAAA skipped"}, actions(res))
+	require.Contains(t, f.records, "rec1")
+
+	// Later rounds stay quiet about it.
+	res, err = u.update(context.Background(), false)
+	require.NoError(t, err)
+	require.Empty(t, res)
+}
+
+func TestDDNSUpdater_DryRunPlansEveryRound(t *testing.T) {
+	f, srv := newFakeAPI(t)
+	c, err := cloudflare.New(cloudflare.WithAPIToken("tok"), cloudflare.WithBaseURL(srv.URL), cloudflare.WithDryRun())
+	require.NoError(t, err)
+	cfg := &ddnsConfig{zone: "example.com", name: "home", ttl: 1, ipv4: true}
+	u, err := newDDNSUpdater(cfg, c, staticDiscoverer{netutil.IPv4: "203.0.113.1"})
+	require.NoError(t, err)
+	u.dryRun = true
+
+	// Nothing was created, so the second round plans the same change.
+	for range 2 {
+		res, err := u.update(context.Background(), false)
+		require.NoError(t, err)
+		require.Equal(t, []string{"A created"}, actions(res))
+	}
+	require.Empty(t, u.published)
+	require.Empty(t, f.records)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+)
+
+// Exit codes. They are part of the CLI's interface for automation and are
+// documented in the README; never renumber them.
+const (
+	exitOK = 0
+	// exitError is any failure not covered by a more specific code.
+	exitError = 1
+	// exitUsage reports invalid flags, arguments or configuration files.
+	exitUsage = 2
+	// exitChanged reports success with changes, with -detailed-exit-code.
+	exitChanged = 3
+	// exitAuth reports rejected credentials or missing permissions.
+	exitAuth = 4
+	// exitNotFound reports a missing zone or record.
+	exitNotFound = 5
+	// exitRateLimited reports Cloudflare rate limiting that outlasted retries.
+	exitRateLimited = 6
+	// exitDiscovery reports that the public IP could not be determined.
+	exitDiscovery = 7
+	// exitAPI reports other Cloudflare API, network and timeout failures.
+	exitAPI = 8
+	// exitPartial reports that some, but not all, records of a run failed.
+	exitPartial = 9
+)
+
+// usageError marks errors caused by the invocation rather than by Cloudflare
+// or the network.
+type usageError struct{ error }
+
+func (e usageError) Unwrap() error { return e.error }
+
+// usagef returns a usageError with a formatted message.
+func usagef(format string, args ...any) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// discoveryError marks public IP discovery failures.
+type discoveryError struct{ error }
+
+func (e discoveryError) Unwrap() error { return e.error }
+
+// authError marks credentials that authenticate but cannot do what the
+// command needs, such as an inactive token or a missing permission.
+type authError struct{ error }
+
+func (e authError) Unwrap() error { return e.error }
+
+// reportedError wraps an error the command has already written to its
+// output, e.g. as a failed record in a DDNS report; main only derives the
+// exit code from it.
+type reportedError struct{ error }
+
+func (e reportedError) Unwrap() error { return e.error }
+
+// fleetError reports the records that failed in a sync round.
+type fleetError struct {
+	errs  []error
+	total int
+}
+
+func (e *fleetError) Error() string {
+	if e.total == 1 {
+		return e.errs[0].Error()
+	}
+	return fmt.Sprintf("%d of %d records failed", len(e.errs), e.total)
+}
+
+func (e *fleetError) Unwrap() []error { return e.errs }
+
+// exitCode maps a command's error to the process exit code.
+func exitCode(err error) int {
+	var (
+		usage     usageError
+		discovery discoveryError
+		auth      authError
+		fleet     *fleetError
+		apiErr    *cloudflare.APIError
+		netErr    net.Error
+	)
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &fleet) && len(fleet.errs) < fleet.total:
+		return exitPartial
+	case errors.As(err, &fleet):
+		return exitCode(fleet.errs[0])
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.As(err, &discovery):
+		return exitDiscovery
+	case errors.As(err, &auth), cloudflare.IsAuth(err):
+		return exitAuth
+	case cloudflare.IsNotFound(err) || errors.Is(err, errNoMatch):
+		return exitNotFound
+	case cloudflare.IsRateLimited(err):
+		return exitRateLimited
+	case errors.As(err, &apiErr), errors.As(err, &netErr), errors.Is(err, context.DeadlineExceeded):
+		return exitAPI
+	}
+	return exitError
+}
+
+// errorClass names the failure class of an exit code in JSON error output.
+func errorClass(code int) string {
+	switch code {
+	case exitUsage:
+		return "usage"
+	case exitAuth:
+		return "auth"
+	case exitNotFound:
+		return "not_found"
+	case exitRateLimited:
+		return "rate_limited"
+	case exitDiscovery:
+		return "discovery"
+	case exitAPI:
+		return "api"
+	case exitPartial:
+		return "partial"
+	}
+	return "error"
+}
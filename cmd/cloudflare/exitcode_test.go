@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden compares got with testdata/name, rewriting the file with -update.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o600))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, string(want), string(got))
+}
+
+func apiError(status int, codes ...int) *cloudflare.APIError {
+	e := &cloudflare.APIError{StatusCode: status, Method: "GET", Path: "/zones"}
+	for _, code := range codes {
+		e.Errors = append(e.Errors, cloudflare.APIMessage{Code: code, Message: "message"})
+	}
+	return e
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "ok", err: nil, want: exitOK},
+		{name: "generic", err: errors.New("boom"), want: exitError},
+		{name: "usage", err: usagef("zone is required"), want: exitUsage},
+		{name: "wrapped usage", err: fmt.Errorf("records[0]: %w", usagef("bad")), want: exitUsage},
+		{name: "auth status", err: apiError(403), want: exitAuth},
+		{name: "auth code", err: apiError(400, 9109), want: exitAuth},
+		{name: "auth check", err: authError{errors.New("token is disabled")}, want: exitAuth},
+		{name: "zone not found", err: fmt.Errorf("%w: example.com", cloudflare.ErrZoneNotFound), want: exitNotFound},
+		{name: "record not found", err: apiError(404, 81044), want: exitNotFound},
+		{name: "no match", err: errNoMatch, want: exitNotFound},
+		{name: "rate limited", err: apiError(429, 10429), want: exitRateLimited},
+		{name: "discovery", err: discoveryError{errors.New("no quorum")}, want: exitDiscovery},
+		{name: "api", err: apiError(500), want: exitAPI},
+		{name: "network", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: exitAPI},
+		{name: "timeout", err: context.DeadlineExceeded, want: exitAPI},
+		{name: "fleet partial", err: &fleetError{errs: []error{apiError(500)}, total: 3}, want: exitPartial},
+		{name: "fleet all failed", err: &fleetError{errs: []error{apiError(403), apiError(500)}, total: 2}, want: exitAuth},
+		{name: "reported", err: reportedError{&fleetError{errs: []error{discoveryError{errors.New("x")}}, total: 1}}, want: exitDiscovery},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, exitCode(tt.err))
+		})
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	want := map[int]string{
+		exitError:       "error",
+		exitUsage:       "usage",
+		exitAuth:        "auth",
+		exitNotFound:    "not_found",
+		exitRateLimited: "rate_limited",
+		exitDiscovery:   "discovery",
+		exitAPI:         "api",
+		exitPartial:     "partial",
+	}
+	for code, class := range want {
+		require.Equal(t, class, errorClass(code), "exit code %d", code)
+	}
+}
+
+func TestPrintError_JSON(t *testing.T) {
+	var stderr bytes.Buffer
+	cf := &clientFlags{output: outputJSON, stderr: &stderr}
+	for _, err := range []error{
+		usagef("zone is required"),
+		apiError(403, 10000),
+		&fleetError{errs: []error{errors.New("boom")}, total: 2},
+	} {
+		printError(cf, err, exitCode(err))
+	}
+	golden(t, "error.json", stderr.Bytes())
+}
+
+func TestDDNSReport_JSON(t *testing.T) {
+	report := ddnsReport{
+		Results: []recordResult{
+			{FQDN: "home.example.com", Type: "A", Old: "203.0.113.1", New: "203.0.113.2", Action: "updated", RecordID: "rec1", DurationMS: 120},
+			{FQDN: "home.example.com", Type: "AAAA", Action: actionSkipped},
+			{FQDN: "nas.example.com", Type: "A", Action: actionFailed, Error: "GET /zones: 403 Forbidden"},
+		},
+		Changed:    1,
+		Failed:     1,
+		DurationMS: 250,
+	}
+	var stdout bytes.Buffer
+	require.NoError(t, renderTo(&stdout, outputJSON, report.view(nil)))
+	golden(t, "ddns_report.json", stdout.Bytes())
+}
+
+func TestDDNSReport_TextFailuresGoToStderr(t *testing.T) {
+	report := ddnsReport{Results: []recordResult{
+		{FQDN: "home.example.com", Type: "A", New: "203.0.113.2", Action: "created"},
+		{FQDN: "nas.example.com", Type: "A", Action: actionFailed, Error: "boom"},
+	}}
+	var stdout, stderr bytes.Buffer
+	require.NoError(t, renderTo(&stdout, outputText, report.view(&stderr)))
+	require.Equal(t, "Created A home.example.com -> 203.0.113.2\n", stdout.String())
+	require.Equal(t, "Failed A nas.example.com: boom\n", stderr.String())
+}
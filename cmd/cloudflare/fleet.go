@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/jsirianni/cloudflare-go/internal/config"
+	"github.com/jsirianni/cloudflare-go/internal/netutil"
+)
+
+// fleet syncs the records of a run, up to concurrency at a time: the single
+// -zone/-name record, or every record of a configuration file with one client
+// per credentials profile and one discoverer per distinct IP source.
+type fleet struct {
+	cf          *clientFlags
+	updaters    []*ddnsUpdater
+	discoverers []*roundDiscoverer
+	concurrency int
+	metrics     *ddnsMetrics
+}
+
+func newFleet(cfg *ddnsConfig, cf *clientFlags) (*fleet, error) {
+	if cfg.zone != "" || cfg.name != "" {
+		return nil, usagef("-zone and -name cannot be combined with -config")
+	}
+	if cfg.concurrency < 1 {
+		return nil, usagef("concurrency must be >= 1")
+	}
+	file, err := config.Load(cfg.configFile)
+	if err != nil {
+		return nil, usageError{err}
+	}
+
+	clients := make(map[string]*cloudflare.Client, len(file.Profiles))
+	for name, p := range file.Profiles {
+		pf := *cf
+		pf.creds = cloudflare.StaticCredentials(cloudflare.Credentials{APIToken: p.APIToken, Email: p.Email, GlobalKey: p.GlobalKey})
+		c, err := pf.newClient()
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", name, err)
+		}
+		clients[name] = c
+	}
+
+	f := &fleet{cf: cf, concurrency: cfg.concurrency, metrics: cfg.metrics}
+	discoverers := make(map[ipSourceKey]*roundDiscoverer)
+	for i, rec := range file.Resolved() {
+		src := recordIPSource(rec, cfg.ipSource, cfg.ipQuorum)
+		d, ok := discoverers[src]
+		if !ok {
+			inner, err := newIPDiscoverer(src.spec, src.quorum, cfg.metrics)
+			if err != nil {
+				return nil, fmt.Errorf("records[%d]: %w", i, err)
+			}
+			d = &roundDiscoverer{inner: inner}
+			discoverers[src] = d
+			f.discoverers = append(f.discoverers, d)
+		}
+		rc := *cfg
+		rc.zone, rc.name = rec.Zone, rec.Name
+		rc.ttl, rc.proxied = *rec.TTL, *rec.Proxied
+		rc.ipv4 = slices.Contains(rec.Types, config.TypeA)
+		rc.ipv6 = slices.Contains(rec.Types, config.TypeAAAA)
+		rc.ipSource, rc.ipQuorum = src.spec, src.quorum
+		rc.ipv6Interface = rec.IPv6Interface
+		rc.deleteAAAA = *rec.DeleteAAAA
+		u, err := newDDNSUpdater(&rc, clients[rec.Profile], d)
+		if err != nil {
+			return nil, fmt.Errorf("records[%d]: %w", i, err)
+		}
+		u.dryRun = cf.dryRun
+		f.updaters = append(f.updaters, u)
+	}
+	return f, nil
+}
+
+// ddnsReport is the result of a sync round. Its JSON encoding is the
+// documented -output json schema of the ddns command.
+type ddnsReport struct {
+	Results []recordResult `json:"results"`
+	// Changed counts created, updated and deleted records; Failed counts
+	// failed results.
+	Changed    int   `json:"changed"`
+	Failed     int   `json:"failed"`
+	DurationMS int64 `json:"duration_ms"`
+	// DryRun means the changes were planned but not made.
+	DryRun bool `json:"dry_run"`
+}
+
+// sync updates every record concurrently, then renders the results in
+// record order. A failing record does not stop the others; the returned
+// error lists the failures, which the report already shows.
+func (f *fleet) sync(ctx context.Context, force bool) error {
+	start := time.Now()
+	for _, d := range f.discoverers {
+		d.reset()
+	}
+	type outcome struct {
+		results []recordResult
+		err     error
+	}
+	outcomes := make([]outcome, len(f.updaters))
+	sem := make(chan struct{}, f.concurrency)
+	var wg sync.WaitGroup
+	for i, u := range f.updaters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			res, err := u.update(ctx, force)
+			outcomes[i] = outcome{res, err}
+		}()
+	}
+	wg.Wait()
+
+	report := ddnsReport{Results: []recordResult{}, DryRun: f.cf.dryRun}
+	var errs []error
+	for _, o := range outcomes {
+		report.Results = append(report.Results, o.results...)
+		if o.err != nil {
+			errs = append(errs, o.err)
+		}
+	}
+	for _, r := range report.Results {
+		switch r.Action {
+		case string(cloudflare.UpsertCreated), string(cloudflare.UpsertUpdated), actionDeleted:
+			report.Changed++
+		case actionFailed:
+			report.Failed++
+		}
+	}
+	report.DurationMS = time.Since(start).Milliseconds()
+	if report.Changed > 0 {
+		f.cf.changed = true
+	}
+
+	var err error
+	if len(errs) > 0 {
+		err = reportedError{&fleetError{errs: errs, total: len(f.updaters)}}
+	}
+	f.metrics.observeSync(report, err)
+
+	// Daemon rounds that skipped every cached record have nothing to say.
+	if len(report.Results) > 0 {
+		if rerr := f.cf.render(report.view(f.cf.stderr)); rerr != nil {
+			return rerr
+		}
+	}
+	return err
+}
+
+// view renders the report; the text format writes failed results to stderr
+// and the others to the output.
+func (r ddnsReport) view(stderr io.Writer) view {
+	return view{
+		value: r,
+		text: func(w io.Writer) error {
+			for _, res := range r.Results {
+				out := w
+				if res.Action == actionFailed {
+					out = stderr
+				}
+				if _, err := fmt.Fprintln(out, res.describe(r.DryRun)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		table: func(w io.Writer) error {
+			rows := make([][]string, 0, len(r.Results))
+			for _, res := range r.Results {
+				rows = append(rows, []string{res.FQDN, res.Type, res.Old, res.New, res.Action, res.RecordID,
+					(time.Duration(res.DurationMS) * time.Millisecond).String(), res.Error})
+			}
+			return writeTable(w, []string{"FQDN", "TYPE", "OLD", "NEW", "ACTION", "RECORD_ID", "DURATION", "ERROR"}, rows)
+		},
+	}
+}
+
+// roundDiscoverer memoizes the addresses found by inner until reset, so
+// records sharing an IP source trigger one discovery per sync round.
+type roundDiscoverer struct {
+	inner netutil.IPDiscoverer
+
+	mu    sync.Mutex
+	cache map[netutil.Family]discovery
+}
+
+type discovery struct {
+	ip  string
+	err error
+}
+
+// Name implements netutil.IPDiscoverer.
+func (d *roundDiscoverer) Name() string { return d.inner.Name() }
+
+func (d *roundDiscoverer) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cache = nil
+}
+
+// DiscoverIP holds the lock while discovering so concurrent callers wait for
+// the first result instead of querying the sources themselves. Context
+// errors are not cached: they belong to the caller, not the source.
+func (d *roundDiscoverer) DiscoverIP(ctx context.Context, family netutil.Family) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if r, ok := d.cache[family]; ok {
+		return r.ip, r.err
+	}
+	ip, err := d.inner.DiscoverIP(ctx, family)
+	if ctx.Err() == nil {
+		if d.cache == nil {
+			d.cache = make(map[netutil.Family]discovery, 2)
+		}
+		d.cache[family] = discovery{ip, err}
+	}
+	return ip, err
+}
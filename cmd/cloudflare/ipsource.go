@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/jsirianni/cloudflare-go/internal/netutil"
+)
+
+// ipSourceHelp documents the -ip-source syntax.
+const ipSourceHelp = "Comma-separated public IP sources: ipify, icanhazip, cloudflare, url:<URL>, dns[:cloudflare|opendns], stun[:HOST:PORT], interface[:NAME], natpmp[:GATEWAY], pcp[:GATEWAY], upnp[:DESCRIPTION_URL]"
+
+// newIPDiscoverer builds the discoverer described by a -ip-source spec. More
+// than one source is combined into a quorum that needs quorum of them to agree
+// (0 means a majority). Every source, and the quorum, report to m.
+func newIPDiscoverer(spec string, quorum int, m *ddnsMetrics) (netutil.IPDiscoverer, error) {
+	var sources []netutil.IPDiscoverer
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		d, err := newIPSource(s)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, m.timed(d))
+	}
+	switch {
+	case len(sources) == 0:
+		return nil, usagef("ip-source must name at least one source")
+	case quorum < 0 || quorum > len(sources):
+		return nil, usagef("ip-quorum must be between 0 and %d", len(sources))
+	case len(sources) == 1:
+		return sources[0], nil
+	}
+	return m.timed(netutil.NewQuorum(quorum, sources...)), nil
+}
+
+// newIPSource builds a single discoverer from its -ip-source name.
+func newIPSource(name string) (netutil.IPDiscoverer, error) {
+	kind, arg, _ := strings.Cut(name, ":")
+	switch strings.ToLower(kind) {
+	case "ipify":
+		return netutil.NewIpify(nil), nil
+	case "icanhazip":
+		return netutil.NewIcanhazip(nil), nil
+	case "cloudflare":
+		return netutil.NewCloudflareTrace(nil), nil
+	case "url":
+		if !strings.HasPrefix(arg, "https://") && !strings.HasPrefix(arg, "http://") {
+			return nil, usagef("ip source %q: url must start with http:// or https://", name)
+		}
+		return netutil.NewURLDiscoverer(arg, nil), nil
+	case "dns":
+		switch strings.ToLower(arg) {
+		case "", "cloudflare":
+			return netutil.NewCloudflareDNS(), nil
+		case "opendns":
+			return netutil.NewOpenDNS(), nil
+		}
+	case "stun":
+		if arg == "" {
+			return netutil.NewSTUN(), nil
+		}
+		return netutil.NewSTUN(arg), nil
+	case "interface":
+		return netutil.NewInterfaceDiscoverer(arg), nil
+	case "natpmp":
+		return netutil.NewNATPMP(arg), nil
+	case "pcp":
+		return netutil.NewPCP(arg), nil
+	case "upnp":
+		return netutil.NewUPnP(arg), nil
+	}
+	return nil, usagef("unknown ip source %q", name)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Formats accepted by -log-format.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// logFormatFlag is the -log-format flag value; Set rejects unknown formats.
+type logFormatFlag string
+
+func (f *logFormatFlag) String() string { return string(*f) }
+
+func (f *logFormatFlag) Set(s string) error {
+	switch s {
+	case logFormatText, logFormatJSON:
+		*f = logFormatFlag(s)
+		return nil
+	}
+	return fmt.Errorf("unknown log format %q (want text or json)", s)
+}
+
+// logger returns the logger configured by -log-level and -log-format, writing
+// to stderr, and installs it as the slog and log package default so every
+// log line of the run is formatted alike. It is built on first use, which
+// must come after the command's flags are parsed.
+func (f *clientFlags) logger() *slog.Logger {
+	if f.log == nil {
+		f.log = newLogger(f.stderr, f.logFormat, f.logLevel)
+		slog.SetDefault(f.log)
+	}
+	return f.log
+}
+
+func newLogger(w io.Writer, format logFormatFlag, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == logFormatJSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
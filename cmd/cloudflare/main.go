@@ -1,121 +1,307 @@
-// Command cloudflare provides a CLI to synchronize a Cloudflare DNS A record
-// with the machine's current public IP, using the reusable cloudflare client.
+// Command cloudflare is a CLI for the Cloudflare API built on the reusable
+// cloudflare client: its ddns command keeps DNS records pointed at the
+// machine's current public IP, and further commands manage zones and records
+// directly or declaratively.
 package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
-	"net/http"
+	"io"
+	"log/slog"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/jsirianni/cloudflare-go/cloudflare"
-	"github.com/jsirianni/cloudflare-go/internal/netutil"
 )
 
+// command is the entry point of a subcommand. cf holds the credential and
+// connection flags given before the subcommand name; commands register them
+// again so they may also follow it.
+type command func(cf *clientFlags, args []string) error
+
+// commands maps subcommand names to their entry points.
+var commands = map[string]command{
+	"ddns":   runDDNS,
+	"zones":  runZones,
+	"dns":    runDNS,
+	"plan":   func(cf *clientFlags, args []string) error { return runReconcile(cf, "plan", args) },
+	"apply":  func(cf *clientFlags, args []string) error { return runReconcile(cf, "apply", args) },
+	"config": runConfig,
+	"auth":   runAuth,
+}
+
 func main() {
-	var (
-		zone      = flag.String("zone", envOr("ZONE", ""), "Cloudflare zone (apex domain)")
-		name      = flag.String("name", envOr("NAME", ""), "Record name/label within the zone")
-		ttl       = flag.Int("ttl", envOrInt("TTL", 1), "TTL in seconds (1=auto)")
-		proxied   = flag.Bool("proxied", envOrBool("PROXIED", false), "Whether the record is proxied")
-		email     = flag.String("email", envOr("CF_EMAIL", ""), "Cloudflare account email (Global Key auth)")
-		globalKey = flag.String("global-key", envOr("CF_GLOBAL_KEY", ""), "Cloudflare Global API Key")
-		apiToken  = flag.String("api-token", envOr("CF_API_TOKEN", ""), "Cloudflare API Token (preferred)")
-		timeout   = flag.Duration("timeout", envOrDuration("TIMEOUT", 30*time.Second), "Overall timeout")
-	)
-	flag.Parse()
+	os.Exit(runCLI(newClientFlags(), os.Args[1:]))
+}
+
+// runCLI runs the command named by args and returns the process exit code.
+func runCLI(cf *clientFlags, args []string) int {
+	global := flag.NewFlagSet("cloudflare", flag.ContinueOnError)
+	global.SetOutput(io.Discard)
+	cf.register(global)
 
-	if err := run(*zone, *name, *ttl, *proxied, *email, *globalKey, *apiToken, *timeout); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	run := runDDNS
+	switch err := global.Parse(args); {
+	case errors.Is(err, flag.ErrHelp):
+		usage(cf.stderr, global)
+		return exitOK
+	case err != nil && !strings.HasPrefix(err.Error(), "flag provided but not defined"):
+		// Undefined flags fall through to ddns below; bad values of shared
+		// flags are errors.
+		fmt.Fprintln(cf.stderr, err)
+		usage(cf.stderr, global)
+		return exitUsage
+	case err == nil && global.NArg() > 0:
+		cmd, ok := commands[global.Arg(0)]
+		if !ok {
+			fmt.Fprintf(cf.stderr, "unknown command %q\n", global.Arg(0))
+			usage(cf.stderr, global)
+			return exitUsage
+		}
+		run, args = cmd, global.Args()[1:]
+	default:
+		// Without a command every flag belongs to ddns, which was the only
+		// behavior before subcommands existed; keep it working for cron jobs.
+		fmt.Fprintln(cf.stderr, "warning: running without a command is deprecated; use `cloudflare ddns`")
 	}
+	err := run(cf, args)
+	code := exitCode(err)
+	if code == exitOK && cf.detailedExitCode && cf.changed {
+		code = exitChanged
+	}
+	var reported reportedError
+	if err != nil && !errors.As(err, &reported) {
+		printError(cf, err, code)
+	}
+	return code
 }
 
-func run(zone, name string, ttl int, proxied bool, email, globalKey, apiToken string, timeout time.Duration) error {
-	if err := validateInputs(zone, name, ttl, email, globalKey, apiToken); err != nil {
-		return err
+// printError writes err to stderr; with -output json as an object carrying
+// the failure class and exit code.
+func printError(cf *clientFlags, err error, code int) {
+	if cf.output != outputJSON {
+		fmt.Fprintln(cf.stderr, err)
+		return
 	}
+	json.NewEncoder(cf.stderr).Encode(struct {
+		Error    string `json:"error"`
+		Class    string `json:"class"`
+		ExitCode int    `json:"exit_code"`
+	}{err.Error(), errorClass(code), code})
+}
 
-	// Context with cancel on interrupt and deadline
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-	ctx = withSignalCancel(ctx, cancel)
-
-	// Construct client
-	var (
-		c   *cloudflare.Client
-		err error
-	)
-	if apiToken != "" && email == "" && globalKey == "" {
-		c, err = cloudflare.New(cloudflare.WithAPIToken(apiToken))
-	} else if apiToken == "" && email != "" && globalKey != "" {
-		c, err = cloudflare.New(cloudflare.WithGlobalKey(email, globalKey))
-	} else {
-		return errors.New("provide either api-token or email+global-key, not both")
+func usage(w io.Writer, global *flag.FlagSet) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
 	}
-	if err != nil {
-		return err
+	sort.Strings(names)
+	fmt.Fprintf(w, "usage: cloudflare [flags] <%s> [command flags]\n\nflags:\n", strings.Join(names, "|"))
+	global.SetOutput(w)
+	global.PrintDefaults()
+}
+
+// dispatch runs the command of group named by args[0], e.g. `cloudflare dns list`.
+func dispatch(group string, cmds map[string]command, cf *clientFlags, args []string) error {
+	names := make([]string, 0, len(cmds))
+	for name := range cmds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(args) == 0 {
+		return usagef("usage: cloudflare %s <%s> [flags]", group, strings.Join(names, "|"))
 	}
+	cmd, ok := cmds[args[0]]
+	if !ok {
+		return usagef("unknown %s command %q (want one of %s)", group, args[0], strings.Join(names, ", "))
+	}
+	return cmd(cf, args[1:])
+}
 
-	// Discover IP
-	wanIP, err := netutil.DiscoverIPv4ViaIpify(ctx, &http.Client{Timeout: 10 * time.Second})
-	if err != nil {
-		return fmt.Errorf("could not determine WAN IP: %w", err)
+// clientFlags holds the credential, connection and output flags shared by
+// every command.
+type clientFlags struct {
+	// email, globalKey and apiToken are the credential flags; without them
+	// credentials come from the environment or the credentials file.
+	email     string
+	globalKey string
+	apiToken  string
+	// profile names the credentials file profile to use.
+	profile string
+	// creds is the credential provider built by credentials.
+	creds   cloudflare.CredentialProvider
+	baseURL string
+	timeout time.Duration
+	retries int
+	output  outputFlag
+	// dryRun makes the client simulate every mutating request.
+	dryRun bool
+	// detailedExitCode makes a successful run that changed something exit
+	// with exitChanged; commands set changed when they do.
+	detailedExitCode bool
+	changed          bool
+	// meter, if set, receives the API client metrics.
+	meter cloudflare.Meter
+	// logLevel and logFormat configure log, the stderr logger built by
+	// logger.
+	logLevel  slog.Level
+	logFormat logFormatFlag
+	log       *slog.Logger
+	// stdout receives command output; stderr errors, warnings and logs.
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// newClientFlags returns the flag defaults taken from the environment.
+func newClientFlags() *clientFlags {
+	f := &clientFlags{
+		profile:   envOr("CF_PROFILE", ""),
+		baseURL:   envOr("CF_BASE_URL", ""),
+		timeout:   envOrDuration("TIMEOUT", 30*time.Second),
+		retries:   envOrInt("RETRIES", 3),
+		output:    outputText,
+		logFormat: logFormatText,
+		stdout:    os.Stdout,
+		stderr:    os.Stderr,
+	}
+	if v := os.Getenv("OUTPUT"); v != "" {
+		_ = f.output.Set(v)
+	}
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		_ = f.logLevel.UnmarshalText([]byte(v))
 	}
+	if v := os.Getenv("LOG_FORMAT"); v != "" {
+		_ = f.logFormat.Set(v)
+	}
+	f.dryRun = envOrBool("DRY_RUN", false)
+	f.detailedExitCode = envOrBool("DETAILED_EXIT_CODE", false)
+	return f
+}
+
+// register adds the flags to fs, defaulting to the current values so flags
+// parsed earlier (before the command name) carry over.
+func (f *clientFlags) register(fs *flag.FlagSet) {
+	// Secrets are registered with an empty default so usage output does not
+	// print them, then restored.
+	globalKey, apiToken := f.globalKey, f.apiToken
+	fs.StringVar(&f.email, "email", f.email, "Cloudflare account email (Global Key auth)")
+	fs.StringVar(&f.globalKey, "global-key", "", "Cloudflare Global API Key")
+	fs.StringVar(&f.apiToken, "api-token", "", "Cloudflare API Token (preferred; visible in ps, see CF_API_TOKEN_FILE and -profile)")
+	f.globalKey, f.apiToken = globalKey, apiToken
+	fs.StringVar(&f.profile, "profile", f.profile, "Credentials `profile` in ~/.config/cloudflare/credentials (default: the environment, then [default])")
+	fs.StringVar(&f.baseURL, "base-url", f.baseURL, "Cloudflare API base URL, e.g. a local fake API (empty for https://api.cloudflare.com/client/v4)")
+	fs.DurationVar(&f.timeout, "timeout", f.timeout, "Overall timeout")
+	fs.IntVar(&f.retries, "retries", f.retries, "Retries for transient Cloudflare API failures (0 disables)")
+	fs.Var(&f.output, "output", "Output `format`: text, json, yaml or table")
+	fs.TextVar(&f.logLevel, "log-level", f.logLevel, "Log `level`: debug, info, warn or error; debug logs every API request")
+	fs.Var(&f.logFormat, "log-format", "Log `format` on stderr: text or json")
+	fs.BoolVar(&f.dryRun, "dry-run", f.dryRun, "Print what would change without changing anything; reads still reach Cloudflare")
+	fs.BoolVar(&f.detailedExitCode, "detailed-exit-code", f.detailedExitCode, "Exit with 3 instead of 0 when the command changed records")
+}
 
-	// Resolve zone ID
-	zoneID, err := c.FindZoneID(ctx, zone)
+// validate resolves the credentials once and checks that exactly one set is
+// provided.
+func (f *clientFlags) validate() error {
+	if f.profile != "" && (f.apiToken != "" || f.email != "" || f.globalKey != "") {
+		return usagef("-profile cannot be combined with -api-token, -email or -global-key")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+	creds, err := f.credentials().Credentials(ctx)
+	if errors.Is(err, cloudflare.ErrNoCredentials) {
+		return errMissingCredentials
+	}
 	if err != nil {
-		return err
+		return fmt.Errorf("credentials: %w", err)
 	}
+	return validateCredentials(creds.Email, creds.GlobalKey, creds.APIToken)
+}
 
-	fqdn := name + "." + zone
-	rec, err := c.GetARecord(ctx, zoneID, fqdn)
-	if err != nil {
-		return err
+// credentials returns the credential provider of the run, resolved again
+// for every request so rotated secrets are picked up: the -profile profile
+// if given, else the credential flags over the environment (CF_API_TOKEN,
+// CF_EMAIL, CF_GLOBAL_KEY or their _FILE variants) if any flag is set, else
+// the environment followed by the default profile.
+func (f *clientFlags) credentials() cloudflare.CredentialProvider {
+	if f.creds != nil {
+		return f.creds
 	}
-	payload := cloudflare.DNSRecord{Type: "A", Name: name, Content: wanIP, TTL: ttl, Proxied: proxied}
-	if rec != nil {
-		if rec.Content == wanIP {
-			fmt.Printf("No change: %s already points to %s\n", fqdn, wanIP)
-			return nil
-		}
-		if _, err := c.UpdateARecord(ctx, zoneID, rec.ID, payload); err != nil {
-			return err
-		}
-		fmt.Printf("Updated A %s -> %s\n", fqdn, wanIP)
-		return nil
+	env := cloudflare.EnvCredentials()
+	switch {
+	case f.profile != "":
+		f.creds = cloudflare.ProfileCredentials("", f.profile)
+	case f.apiToken == "" && f.email == "" && f.globalKey == "":
+		f.creds = cloudflare.ChainCredentials(env, cloudflare.ProfileCredentials("", ""))
+	default:
+		flags := cloudflare.Credentials{APIToken: f.apiToken, Email: f.email, GlobalKey: f.globalKey}
+		f.creds = cloudflare.CredentialProviderFunc(func(ctx context.Context) (cloudflare.Credentials, error) {
+			creds, err := env.Credentials(ctx)
+			if err != nil && !errors.Is(err, cloudflare.ErrNoCredentials) {
+				return creds, err
+			}
+			return overrideCredentials(creds, flags), nil
+		})
 	}
-	if _, err := c.CreateARecord(ctx, zoneID, payload); err != nil {
-		return err
+	return f.creds
+}
+
+// overrideCredentials returns creds with the fields set in flags replaced.
+func overrideCredentials(creds, flags cloudflare.Credentials) cloudflare.Credentials {
+	if flags.APIToken != "" {
+		creds.APIToken = flags.APIToken
 	}
-	fmt.Printf("Created A %s -> %s\n", fqdn, wanIP)
-	return nil
+	if flags.Email != "" {
+		creds.Email = flags.Email
+	}
+	if flags.GlobalKey != "" {
+		creds.GlobalKey = flags.GlobalKey
+	}
+	return creds
 }
 
-func validateInputs(zone, name string, ttl int, email, globalKey, apiToken string) error {
-	if strings.TrimSpace(zone) == "" {
-		return errors.New("zone is required")
+// newClient constructs a cloudflare client for the configured credentials.
+func (f *clientFlags) newClient() (*cloudflare.Client, error) {
+	retry := cloudflare.DefaultRetryPolicy()
+	retry.MaxRetries = f.retries
+	opts := []cloudflare.Option{cloudflare.WithRetryPolicy(retry), cloudflare.WithLogger(f.logger())}
+	if f.baseURL != "" {
+		opts = append(opts, cloudflare.WithBaseURL(f.baseURL))
 	}
-	if strings.TrimSpace(name) == "" {
-		return errors.New("name is required")
+	if f.dryRun {
+		opts = append(opts, cloudflare.WithDryRun())
 	}
-	if ttl < 0 {
-		return errors.New("ttl must be >= 0 (1 for auto)")
+	if f.meter != nil {
+		opts = append(opts, cloudflare.WithMeter(f.meter))
 	}
+	opts = append(opts, cloudflare.WithCredentials(f.credentials()))
+	return cloudflare.New(opts...)
+}
+
+// context returns a context bounded by -timeout and canceled on interrupt.
+func (f *clientFlags) context() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	return withSignalCancel(ctx, cancel), cancel
+}
+
+// errMissingCredentials is returned when no credential source is configured.
+var errMissingCredentials = usagef("missing credentials: set CF_API_TOKEN or CF_EMAIL and CF_GLOBAL_KEY (or their _FILE variants), or add a profile to ~/.config/cloudflare/credentials")
+
+func validateCredentials(email, globalKey, apiToken string) error {
 	haveGlobal := email != "" && globalKey != ""
 	haveToken := apiToken != ""
 	if haveGlobal && haveToken {
-		return errors.New("provide either api-token or email+global-key, not both")
+		return usagef("provide either api-token or email+global-key, not both")
 	}
 	if !haveGlobal && !haveToken {
-		return errors.New("missing credentials: set CF_API_TOKEN or CF_EMAIL and CF_GLOBAL_KEY")
+		return errMissingCredentials
 	}
 	return nil
 }
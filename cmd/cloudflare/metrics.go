@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/jsirianni/cloudflare-go/internal/metrics"
+	"github.com/jsirianni/cloudflare-go/internal/netutil"
+)
+
+// ddnsMetrics collects the daemon's Prometheus metrics and the sync state
+// behind /healthz and /readyz. It also serves as the clients' cloudflare.Meter.
+// A nil *ddnsMetrics records nothing.
+type ddnsMetrics struct {
+	reg             *metrics.Registry
+	ipInfo          *metrics.Gauge
+	lastSync        *metrics.Gauge
+	lastSuccess     *metrics.Gauge
+	syncs           *metrics.Counter
+	updates         *metrics.Counter
+	discovery       *metrics.Histogram
+	discoveryErrors *metrics.Counter
+	apiRequests     *metrics.Counter
+	apiErrors       *metrics.Counter
+	apiDuration     *metrics.Histogram
+
+	// unhealthyAfter is how long syncs may fail before /healthz fails.
+	unhealthyAfter time.Duration
+
+	mu sync.Mutex
+	// ips maps source and family to the address last reported in ipInfo.
+	ips          map[[2]string]string
+	lastOK       time.Time
+	failingSince time.Time
+	lastErr      error
+}
+
+func newDDNSMetrics(unhealthyAfter time.Duration) *ddnsMetrics {
+	reg := metrics.NewRegistry()
+	return &ddnsMetrics{
+		reg:             reg,
+		ipInfo:          reg.NewGauge("cloudflare_ddns_ip_info", "Address last discovered by each IP source; the value is always 1.", "source", "family", "ip"),
+		lastSync:        reg.NewGauge("cloudflare_ddns_last_sync_timestamp_seconds", "Unix time of the last sync, successful or not."),
+		lastSuccess:     reg.NewGauge("cloudflare_ddns_last_success_timestamp_seconds", "Unix time of the last sync in which every record succeeded."),
+		syncs:           reg.NewCounter("cloudflare_ddns_syncs_total", "Sync rounds by result (success or failure).", "result"),
+		updates:         reg.NewCounter("cloudflare_ddns_record_updates_total", "Record results by action (unchanged, created, updated, deleted, skipped, failed).", "action"),
+		discovery:       reg.NewHistogram("cloudflare_ddns_discovery_duration_seconds", "Public IP discovery latency per source.", metrics.DefBuckets, "source", "family"),
+		discoveryErrors: reg.NewCounter("cloudflare_ddns_discovery_errors_total", "Failed public IP discoveries per source.", "source", "family"),
+		apiRequests:     reg.NewCounter("cloudflare_api_requests_total", "Cloudflare API calls by method, endpoint template and status.", "method", "endpoint", "status"),
+		apiErrors:       reg.NewCounter("cloudflare_api_errors_total", "Failed Cloudflare API calls by Cloudflare error code, or by HTTP status or transport error when the response has none.", "code"),
+		apiDuration:     reg.NewHistogram("cloudflare_api_request_duration_seconds", "Cloudflare API call latency, retries included.", metrics.DefBuckets, "method", "endpoint"),
+		unhealthyAfter:  unhealthyAfter,
+		ips:             make(map[[2]string]string),
+	}
+}
+
+// Add implements cloudflare.Meter.
+func (m *ddnsMetrics) Add(_ context.Context, name string, delta int64, attrs ...cloudflare.Attribute) {
+	switch name {
+	case cloudflare.MetricRequests:
+		m.apiRequests.Add(float64(delta), attr(attrs, cloudflare.AttrMethod), attr(attrs, cloudflare.AttrTemplate), attr(attrs, cloudflare.AttrStatusCode))
+	case cloudflare.MetricErrors:
+		code := attr(attrs, cloudflare.AttrErrorCode)
+		if code == "" {
+			code = attr(attrs, cloudflare.AttrErrorType)
+		}
+		m.apiErrors.Add(float64(delta), code)
+	}
+}
+
+// Record implements cloudflare.Meter.
+func (m *ddnsMetrics) Record(_ context.Context, name string, value float64, attrs ...cloudflare.Attribute) {
+	if name == cloudflare.MetricRequestDuration {
+		m.apiDuration.Observe(value, attr(attrs, cloudflare.AttrMethod), attr(attrs, cloudflare.AttrTemplate))
+	}
+}
+
+// attr returns the value of the attribute key as a label value.
+func attr(attrs []cloudflare.Attribute, key string) string {
+	for _, a := range attrs {
+		if a.Key == key {
+			return fmt.Sprint(a.Value)
+		}
+	}
+	return ""
+}
+
+// timed wraps d so its discoveries are measured.
+func (m *ddnsMetrics) timed(d netutil.IPDiscoverer) netutil.IPDiscoverer {
+	if m == nil {
+		return d
+	}
+	return &timedDiscoverer{inner: d, m: m}
+}
+
+// timedDiscoverer records the latency, failures and results of inner.
+type timedDiscoverer struct {
+	inner netutil.IPDiscoverer
+	m     *ddnsMetrics
+}
+
+// Name implements netutil.IPDiscoverer.
+func (d *timedDiscoverer) Name() string { return d.inner.Name() }
+
+// DiscoverIP implements netutil.IPDiscoverer.
+func (d *timedDiscoverer) DiscoverIP(ctx context.Context, family netutil.Family) (string, error) {
+	start := time.Now()
+	ip, err := d.inner.DiscoverIP(ctx, family)
+	source, fam := d.Name(), strings.ToLower(family.String())
+	d.m.discovery.Observe(time.Since(start).Seconds(), source, fam)
+	switch {
+	case err == nil:
+		d.m.setIP(source, fam, ip)
+	case errors.Is(err, netutil.ErrNoIPv6):
+		d.m.setIP(source, fam, "")
+	case ctx.Err() == nil:
+		d.m.discoveryErrors.Inc(source, fam)
+	}
+	return ip, err
+}
+
+// setIP replaces the ip info series of source and family; an empty ip only
+// removes it.
+func (m *ddnsMetrics) setIP(source, family, ip string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := [2]string{source, family}
+	if prev, ok := m.ips[key]; ok && prev != ip {
+		m.ipInfo.Delete(source, family, prev)
+	}
+	if ip == "" {
+		delete(m.ips, key)
+		return
+	}
+	m.ips[key] = ip
+	m.ipInfo.Set(1, source, family, ip)
+}
+
+// observeSync records the outcome of a sync round.
+func (m *ddnsMetrics) observeSync(report ddnsReport, err error) {
+	if m == nil {
+		return
+	}
+	for _, r := range report.Results {
+		m.updates.Inc(r.Action)
+	}
+	now := time.Now()
+	m.lastSync.Set(float64(now.Unix()))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.syncs.Inc("failure")
+		if m.failingSince.IsZero() {
+			m.failingSince = now
+		}
+		m.lastErr = err
+		return
+	}
+	m.syncs.Inc("success")
+	m.lastSuccess.Set(float64(now.Unix()))
+	m.lastOK, m.failingSince, m.lastErr = now, time.Time{}, nil
+}
+
+// health reports whether syncs have been failing for at most unhealthyAfter.
+func (m *ddnsMetrics) health(now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.failingSince.IsZero() && now.Sub(m.failingSince) > m.unhealthyAfter {
+		return fmt.Errorf("syncs failing since %s: %v", m.failingSince.Format(time.RFC3339), m.lastErr)
+	}
+	return nil
+}
+
+// ready reports whether a sync has succeeded and the daemon is healthy.
+func (m *ddnsMetrics) ready(now time.Time) error {
+	if err := m.health(now); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lastOK.IsZero() {
+		return errors.New("no successful sync yet")
+	}
+	return nil
+}
+
+// serve exposes /metrics, /healthz and /readyz on addr until ctx is done.
+// Listen errors are returned right away.
+func (m *ddnsMetrics) serve(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", m.reg.Handler())
+	mux.HandleFunc("GET /healthz", statusHandler(m.health))
+	mux.HandleFunc("GET /readyz", statusHandler(m.ready))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("metrics server failed", "error", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	slog.Info("serving metrics", "url", "http://"+ln.Addr().String()+"/metrics")
+	return nil
+}
+
+// statusHandler answers 200 "ok" when check passes and 503 with the reason
+// otherwise.
+func statusHandler(check func(time.Time) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := check(time.Now()); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, err)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Formats accepted by -output.
+const (
+	outputText  = "text"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputTable = "table"
+)
+
+// outputFlag is the -output flag value; Set rejects unknown formats so they
+// fail flag parsing.
+type outputFlag string
+
+func (o *outputFlag) String() string { return string(*o) }
+
+func (o *outputFlag) Set(s string) error {
+	switch s {
+	case outputText, outputJSON, outputYAML, outputTable:
+		*o = outputFlag(s)
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q (want text, json, yaml or table)", s)
+}
+
+// view is how a command result renders in each -output format. JSON and YAML
+// encode value, whose JSON encoding is the stable, documented schema; text
+// and table call their writers, and a nil table falls back to text.
+type view struct {
+	value any
+	text  func(w io.Writer) error
+	table func(w io.Writer) error
+}
+
+// render writes v to stdout in the -output format.
+func (f *clientFlags) render(v view) error {
+	return renderTo(f.stdout, string(f.output), v)
+}
+
+func renderTo(w io.Writer, format string, v view) error {
+	switch format {
+	case outputJSON:
+		// One document per line, so daemon output is a JSON Lines stream.
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(v.value)
+	case outputYAML:
+		b, err := json.Marshal(v.value)
+		if err != nil {
+			return err
+		}
+		return jsonToYAML(w, b)
+	case outputTable:
+		if v.table != nil {
+			return v.table(w)
+		}
+	}
+	return v.text(w)
+}
+
+// writeTable writes rows under header as aligned columns.
+func writeTable(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// jsonToYAML converts a JSON document to a YAML document, keeping the order
+// of object keys so YAML output lists fields in the same order as JSON.
+func jsonToYAML(w io.Writer, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := decodeNode(dec)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	b.WriteString("---\n")
+	writeYAMLNode(&b, node, 0)
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// yamlField is a key/value pair of an object, kept in document order.
+type yamlField struct {
+	key   string
+	value any
+}
+
+// decodeNode reads one JSON value: objects become []yamlField, arrays []any,
+// and scalars stay as decoded (json.Number, string, bool or nil).
+func decodeNode(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		fields := []yamlField{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, yamlField{key.(string), value})
+		}
+		_, err := dec.Token()
+		return fields, err
+	case json.Delim('['):
+		items := []any{}
+		for dec.More() {
+			item, err := decodeNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err := dec.Token()
+		return items, err
+	}
+	return tok, nil
+}
+
+// writeYAMLNode writes node in block style at the given indentation. Empty
+// objects and arrays use flow style ({} and []).
+func writeYAMLNode(b *strings.Builder, node any, indent int) {
+	pad := strings.Repeat(" ", indent)
+	switch n := node.(type) {
+	case []yamlField:
+		if len(n) == 0 {
+			b.WriteString(pad + "{}\n")
+			return
+		}
+		for _, f := range n {
+			b.WriteString(pad + yamlScalar(f.key) + ":")
+			writeYAMLValue(b, f.value, indent+2)
+		}
+	case []any:
+		if len(n) == 0 {
+			b.WriteString(pad + "[]\n")
+			return
+		}
+		for _, item := range n {
+			b.WriteString(pad + "-")
+			writeYAMLValue(b, item, indent+2)
+		}
+	default:
+		b.WriteString(pad + yamlScalar(n) + "\n")
+	}
+}
+
+// writeYAMLValue writes the value following a "key:" or "-" already on the
+// line: scalars and empty collections inline, collections on the next lines.
+// A collection inside a sequence starts on the dash line.
+func writeYAMLValue(b *strings.Builder, value any, indent int) {
+	switch v := value.(type) {
+	case []yamlField:
+		if len(v) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+	case []any:
+		if len(v) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+	default:
+		b.WriteString(" " + yamlScalar(v) + "\n")
+		return
+	}
+	var nested strings.Builder
+	writeYAMLNode(&nested, value, indent)
+	s := nested.String()
+	if strings.HasSuffix(b.String(), "-") {
+		b.WriteString(" " + s[indent:])
+		return
+	}
+	b.WriteString("\n" + s)
+}
+
+// yamlScalar formats a JSON scalar, quoting strings that YAML would otherwise
+// read as another type or that contain syntax characters.
+func yamlScalar(v any) string {
+	switch s := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(s)
+	case json.Number:
+		return s.String()
+	case string:
+		if yamlPlain(s) {
+			return s
+		}
+		b, _ := json.Marshal(s)
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+func yamlPlain(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return false
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return false
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	// YAML 1.1 reads digits separated by colons as base 60 numbers.
+	if strings.Contains(s, ":") && strings.Trim(s, "0123456789:._") == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
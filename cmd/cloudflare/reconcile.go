@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+)
+
+// runReconcile implements the plan and apply commands: both diff the zone
+// against a desired-state file and print the plan; apply also executes it.
+func runReconcile(cf *clientFlags, mode string, args []string) error {
+	fs := flag.NewFlagSet("cloudflare "+mode, flag.ExitOnError)
+	var (
+		zone      = fs.String("zone", envOr("ZONE", ""), "Cloudflare zone (apex domain)")
+		file      = fs.String("file", "", "Desired records file")
+		format    = fs.String("format", "json", "Desired records file format: json (array of DNS records) or bind (RFC 1035 zone file)")
+		owner     = fs.String("owner", cloudflare.DefaultOwnerMarker, "Comment marker identifying records managed by this tool")
+		manageAll = fs.Bool("manage-all", false, "Treat every record in the zone as managed, including records without the owner marker")
+	)
+	cf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*zone) == "" {
+		return usagef("zone is required")
+	}
+	if *file == "" {
+		return usagef("file is required")
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+	desired, err := loadDesired(*file, *format, *zone)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+	c, err := cf.newClient()
+	if err != nil {
+		return err
+	}
+	zoneID, err := c.FindZoneID(ctx, *zone)
+	if err != nil {
+		return err
+	}
+
+	plan, err := c.Reconcile(ctx, zoneID, desired, cloudflare.ReconcileOptions{
+		ZoneName:    *zone,
+		OwnerMarker: *owner,
+		ManageAll:   *manageAll,
+		PlanOnly:    mode == "plan",
+	})
+	if plan != nil {
+		if plan.Changes == nil {
+			plan.Changes = []cloudflare.Change{}
+		}
+		cf.changed = plan.HasChanges()
+		if rerr := cf.render(planView(plan, mode == "apply" && err == nil, cf.dryRun)); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+// planView renders the plan; its JSON encoding is the cloudflare.Plan.
+func planView(plan *cloudflare.Plan, applied, dryRun bool) view {
+	return view{
+		value: plan,
+		text: func(w io.Writer) error {
+			fmt.Fprint(w, plan)
+			switch {
+			case applied && plan.HasChanges() && dryRun:
+				fmt.Fprintln(w, "Dry run: no changes were applied.")
+			case applied && plan.HasChanges():
+				fmt.Fprintln(w, "Apply complete.")
+			}
+			return nil
+		},
+		table: func(w io.Writer) error {
+			rows := make([][]string, 0, len(plan.Changes))
+			for _, ch := range plan.Changes {
+				r := ch.Desired
+				if r == nil {
+					r = ch.Existing
+				}
+				rows = append(rows, []string{string(ch.Action), r.Type, r.Name, recordContent(*r), ttlString(r.TTL), strconv.FormatBool(r.Proxied)})
+			}
+			return writeTable(w, []string{"ACTION", "TYPE", "NAME", "CONTENT", "TTL", "PROXIED"}, rows)
+		},
+	}
+}
+
+// loadDesired reads desired records from a JSON array of DNS records (names may
+// be labels or FQDNs) or from a BIND zone file with the zone as origin.
+func loadDesired(path, format, zone string) ([]cloudflare.DNSRecord, error) {
+	b, err := os.ReadFile(path) // #nosec G304 -- path is provided by the operator
+	if err != nil {
+		return nil, err
+	}
+	var records []cloudflare.DNSRecord
+	switch format {
+	case "json":
+		err = json.Unmarshal(b, &records)
+	case "bind":
+		records, err = cloudflare.ParseZoneFile(bytes.NewReader(b), zone)
+	default:
+		return nil, usagef("unknown format %q (want json or bind)", format)
+	}
+	if err != nil {
+		return nil, usagef("parse %s: %w", path, err)
+	}
+	return records, nil
+}
@@ -0,0 +1,486 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+)
+
+// errNoMatch is returned when no record matches a selector.
+var errNoMatch = errors.New("no record matches")
+
+// recordSelector holds the flags that pick existing records: an id, or a
+// type/name/content filter. Names may be labels or FQDNs.
+type recordSelector struct {
+	id      string
+	typ     string
+	name    string
+	content string
+}
+
+// register adds the selector flags; contentFlag names the content filter so
+// commands that also set content can rename it.
+func (s *recordSelector) register(fs *flag.FlagSet, withID bool, contentFlag string) {
+	if withID {
+		fs.StringVar(&s.id, "id", "", "Record ID (instead of the filters)")
+	}
+	fs.StringVar(&s.typ, "type", "", "Filter by record type, e.g. AAAA")
+	fs.StringVar(&s.name, "name", "", "Filter by record name (label or FQDN)")
+	fs.StringVar(&s.content, contentFlag, "", "Filter by exact record content")
+}
+
+func (s *recordSelector) filter(zone string) cloudflare.DNSRecordFilter {
+	f := cloudflare.DNSRecordFilter{Type: s.typ, Content: s.content}
+	if s.name != "" {
+		f.Name = cloudflare.FQDN(s.name, zone)
+	}
+	return f
+}
+
+// find returns the records the selector matches.
+func (s *recordSelector) find(ctx context.Context, c *cloudflare.Client, zoneID, zone string) ([]cloudflare.DNSRecord, error) {
+	if s.id != "" {
+		rec, err := c.GetDNSRecord(ctx, zoneID, s.id)
+		if err != nil {
+			return nil, err
+		}
+		return []cloudflare.DNSRecord{*rec}, nil
+	}
+	if s.typ == "" && s.name == "" && s.content == "" {
+		return nil, usagef("select records with id or at least one of type, name and content")
+	}
+	return cloudflare.Collect(c.ListDNSRecords(ctx, zoneID, s.filter(zone)))
+}
+
+// findOne is find for commands that act on a single record.
+func (s *recordSelector) findOne(ctx context.Context, c *cloudflare.Client, zoneID, zone string) (*cloudflare.DNSRecord, error) {
+	recs, err := s.find(ctx, c, zoneID, zone)
+	if err != nil {
+		return nil, err
+	}
+	switch len(recs) {
+	case 0:
+		return nil, errNoMatch
+	case 1:
+		return &recs[0], nil
+	}
+	return nil, usagef("%d records match; narrow the filter or use -id", len(recs))
+}
+
+// openZone returns a client and the id of zone.
+func openZone(ctx context.Context, cf *clientFlags, zone string) (*cloudflare.Client, string, error) {
+	c, err := cf.newClient()
+	if err != nil {
+		return nil, "", err
+	}
+	zoneID, err := c.FindZoneID(ctx, zone)
+	if err != nil {
+		return nil, "", err
+	}
+	slog.DebugContext(ctx, "resolved zone", "zone", zone, "zone_id", zoneID)
+	return c, zoneID, nil
+}
+
+// checkZone validates the zone and credential flags.
+func checkZone(cf *clientFlags, zone string) error {
+	if strings.TrimSpace(zone) == "" {
+		return usagef("zone is required")
+	}
+	return cf.validate()
+}
+
+// runDNSList prints the zone's records matching the filters.
+func runDNSList(cf *clientFlags, args []string) error {
+	fs := flag.NewFlagSet("cloudflare dns list", flag.ExitOnError)
+	zone := fs.String("zone", envOr("ZONE", ""), "Cloudflare zone (apex domain)")
+	var sel recordSelector
+	sel.register(fs, false, "content")
+	cf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkZone(cf, *zone); err != nil {
+		return err
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+	c, zoneID, err := openZone(ctx, cf, *zone)
+	if err != nil {
+		return err
+	}
+	recs, err := cloudflare.Collect(c.ListDNSRecords(ctx, zoneID, sel.filter(*zone)))
+	if err != nil {
+		return err
+	}
+	if recs == nil {
+		recs = []cloudflare.DNSRecord{}
+	}
+	table := func(w io.Writer) error { return writeRecordTable(w, recs...) }
+	return cf.render(view{value: recs, text: table, table: table})
+}
+
+// runDNSGet prints a single record.
+func runDNSGet(cf *clientFlags, args []string) error {
+	fs := flag.NewFlagSet("cloudflare dns get", flag.ExitOnError)
+	zone := fs.String("zone", envOr("ZONE", ""), "Cloudflare zone (apex domain)")
+	var sel recordSelector
+	sel.register(fs, true, "content")
+	cf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkZone(cf, *zone); err != nil {
+		return err
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+	c, zoneID, err := openZone(ctx, cf, *zone)
+	if err != nil {
+		return err
+	}
+	rec, err := sel.findOne(ctx, c, zoneID, *zone)
+	if err != nil {
+		return err
+	}
+	return cf.render(view{
+		value: rec,
+		text:  func(w io.Writer) error { return writeRecord(w, rec) },
+		table: func(w io.Writer) error { return writeRecordTable(w, *rec) },
+	})
+}
+
+// recordValueFlags holds the record fields set by create and update.
+type recordValueFlags struct {
+	content  string
+	ttl      int
+	proxied  bool
+	priority uint
+	comment  string
+	// data is the structured data of SRV, CAA, URI and similar records as
+	// a JSON object.
+	data string
+}
+
+func (v *recordValueFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&v.content, "content", "", "Record content, e.g. an IP address or hostname")
+	fs.IntVar(&v.ttl, "ttl", 1, "TTL in seconds (1=auto)")
+	fs.BoolVar(&v.proxied, "proxied", false, "Whether the record is proxied")
+	fs.UintVar(&v.priority, "priority", 0, "Priority for MX, SRV and URI records")
+	fs.StringVar(&v.comment, "comment", "", "Record comment")
+	fs.StringVar(&v.data, "data", "", `Structured record data as a JSON object, e.g. '{"flags":0,"tag":"issue","value":"letsencrypt.org"}' for CAA`)
+}
+
+// parseData decodes the -data flag.
+func (v *recordValueFlags) parseData() (map[string]any, error) {
+	var data map[string]any
+	if err := json.Unmarshal([]byte(v.data), &data); err != nil || data == nil {
+		return nil, usagef("data must be a JSON object")
+	}
+	return data, nil
+}
+
+// runDNSCreate creates a record.
+func runDNSCreate(cf *clientFlags, args []string) error {
+	fs := flag.NewFlagSet("cloudflare dns create", flag.ExitOnError)
+	var (
+		zone = fs.String("zone", envOr("ZONE", ""), "Cloudflare zone (apex domain)")
+		typ  = fs.String("type", "", "Record type, e.g. A")
+		name = fs.String("name", "", "Record name (label or FQDN)")
+		val  recordValueFlags
+	)
+	val.register(fs)
+	cf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *typ == "" || *name == "" {
+		return usagef("type and name are required")
+	}
+	rec := cloudflare.DNSRecord{
+		Type:    strings.ToUpper(*typ),
+		Name:    cloudflare.FQDN(*name, *zone),
+		Content: val.content,
+		TTL:     val.ttl,
+		Proxied: val.proxied,
+		Comment: val.comment,
+	}
+	if flagSet(fs, "priority") {
+		if val.priority > 0xffff {
+			return usagef("priority must be <= 65535")
+		}
+		rec.Priority = cloudflare.Ptr(uint16(val.priority))
+	}
+	if flagSet(fs, "data") {
+		data, err := val.parseData()
+		if err != nil {
+			return err
+		}
+		rec.Data = data
+	}
+	// Catch invalid records before any request is made.
+	if err := cloudflare.ValidateDNSRecord(rec); err != nil {
+		return usageError{err}
+	}
+	if err := checkZone(cf, *zone); err != nil {
+		return err
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+	c, zoneID, err := openZone(ctx, cf, *zone)
+	if err != nil {
+		return err
+	}
+	created, err := c.CreateDNSRecord(ctx, zoneID, rec)
+	if err != nil {
+		return err
+	}
+	cf.changed = true
+	return cf.render(changesView([]recordChange{{actionCreated, *created, cf.dryRun}}))
+}
+
+// runDNSUpdate changes the given fields of one record; fields whose flags are
+// not set keep their current value.
+func runDNSUpdate(cf *clientFlags, args []string) error {
+	fs := flag.NewFlagSet("cloudflare dns update", flag.ExitOnError)
+	zone := fs.String("zone", envOr("ZONE", ""), "Cloudflare zone (apex domain)")
+	var (
+		sel recordSelector
+		val recordValueFlags
+	)
+	sel.register(fs, true, "match-content")
+	val.register(fs)
+	cf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var patch cloudflare.DNSRecordPatch
+	if flagSet(fs, "content") {
+		patch.Content = &val.content
+	}
+	if flagSet(fs, "ttl") {
+		patch.TTL = &val.ttl
+	}
+	if flagSet(fs, "proxied") {
+		patch.Proxied = &val.proxied
+	}
+	if flagSet(fs, "priority") {
+		if val.priority > 0xffff {
+			return usagef("priority must be <= 65535")
+		}
+		patch.Priority = cloudflare.Ptr(uint16(val.priority))
+	}
+	if flagSet(fs, "comment") {
+		patch.Comment = &val.comment
+	}
+	if flagSet(fs, "data") {
+		data, err := val.parseData()
+		if err != nil {
+			return err
+		}
+		patch.Data = data
+	}
+	if patch.Content == nil && patch.TTL == nil && patch.Proxied == nil && patch.Priority == nil && patch.Comment == nil && patch.Data == nil {
+		return usagef("nothing to update: set at least one of content, ttl, proxied, priority, comment and data")
+	}
+	if err := checkZone(cf, *zone); err != nil {
+		return err
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+	c, zoneID, err := openZone(ctx, cf, *zone)
+	if err != nil {
+		return err
+	}
+	rec, err := sel.findOne(ctx, c, zoneID, *zone)
+	if err != nil {
+		return err
+	}
+	if patch.Data != nil {
+		// Check the new data against the record's type.
+		check := *rec
+		check.Data = patch.Data
+		if err := cloudflare.ValidateDNSRecord(check); err != nil {
+			return usageError{err}
+		}
+	}
+	updated, err := c.PatchDNSRecord(ctx, zoneID, rec.ID, patch)
+	if err != nil {
+		return err
+	}
+	cf.changed = true
+	return cf.render(changesView([]recordChange{{actionUpdated, *updated, cf.dryRun}}))
+}
+
+// runDNSDelete deletes the selected record, or every match with -all.
+func runDNSDelete(cf *clientFlags, args []string) error {
+	fs := flag.NewFlagSet("cloudflare dns delete", flag.ExitOnError)
+	var (
+		zone = fs.String("zone", envOr("ZONE", ""), "Cloudflare zone (apex domain)")
+		all  = fs.Bool("all", false, "Delete every matching record instead of requiring exactly one match")
+		sel  recordSelector
+	)
+	sel.register(fs, true, "content")
+	cf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkZone(cf, *zone); err != nil {
+		return err
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+	c, zoneID, err := openZone(ctx, cf, *zone)
+	if err != nil {
+		return err
+	}
+	var recs []cloudflare.DNSRecord
+	if *all {
+		recs, err = sel.find(ctx, c, zoneID, *zone)
+	} else {
+		var rec *cloudflare.DNSRecord
+		if rec, err = sel.findOne(ctx, c, zoneID, *zone); err == nil {
+			recs = append(recs, *rec)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	changes := []recordChange{}
+	for _, r := range recs {
+		if err = c.DeleteDNSRecord(ctx, zoneID, r.ID); err != nil {
+			break
+		}
+		changes = append(changes, recordChange{actionDeleted, r, cf.dryRun})
+	}
+	cf.changed = len(changes) > 0
+	if rerr := cf.render(changesView(changes)); rerr != nil && err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// Actions reported by the mutating dns commands, besides actionDeleted.
+const (
+	actionCreated = string(cloudflare.UpsertCreated)
+	actionUpdated = string(cloudflare.UpsertUpdated)
+)
+
+// recordChange is a record created, updated or deleted by a dns command.
+// A list of them is the -output json schema of those commands.
+type recordChange struct {
+	Action string               `json:"action"`
+	Record cloudflare.DNSRecord `json:"record"`
+	// DryRun means the change was planned but not made.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+func changesView(changes []recordChange) view {
+	return view{
+		value: changes,
+		text: func(w io.Writer) error {
+			for _, ch := range changes {
+				r := ch.Record
+				switch {
+				case ch.DryRun && ch.Action == actionDeleted:
+					fmt.Fprintf(w, "Would delete %s %s (%s, id %s)\n", r.Type, r.Name, recordContent(r), r.ID)
+				case ch.DryRun && ch.Action == actionCreated:
+					fmt.Fprintf(w, "Would create %s %s -> %s\n", r.Type, r.Name, recordContent(r))
+				case ch.DryRun:
+					fmt.Fprintf(w, "Would update %s %s -> %s (id %s)\n", r.Type, r.Name, recordContent(r), r.ID)
+				case ch.Action == actionDeleted:
+					fmt.Fprintf(w, "Deleted %s %s (%s)\n", r.Type, r.Name, recordContent(r))
+				case ch.Action == actionCreated:
+					fmt.Fprintf(w, "Created %s %s -> %s (id %s)\n", r.Type, r.Name, recordContent(r), r.ID)
+				default:
+					fmt.Fprintf(w, "Updated %s %s -> %s (id %s)\n", r.Type, r.Name, recordContent(r), r.ID)
+				}
+			}
+			return nil
+		},
+		table: func(w io.Writer) error {
+			rows := make([][]string, 0, len(changes))
+			for _, ch := range changes {
+				rows = append(rows, append([]string{ch.Action}, recordRow(ch.Record)...))
+			}
+			return writeTable(w, append([]string{"ACTION"}, recordHeader...), rows)
+		},
+	}
+}
+
+// recordHeader names the columns of recordRow.
+var recordHeader = []string{"ID", "TYPE", "NAME", "CONTENT", "TTL", "PROXIED"}
+
+func recordRow(r cloudflare.DNSRecord) []string {
+	return []string{r.ID, r.Type, r.Name, recordContent(r), ttlString(r.TTL), strconv.FormatBool(r.Proxied)}
+}
+
+func writeRecordTable(w io.Writer, recs ...cloudflare.DNSRecord) error {
+	rows := make([][]string, 0, len(recs))
+	for _, r := range recs {
+		rows = append(rows, recordRow(r))
+	}
+	return writeTable(w, recordHeader, rows)
+}
+
+// writeRecord writes the fields of rec, one per line.
+func writeRecord(w io.Writer, rec *cloudflare.DNSRecord) error {
+	fmt.Fprintf(w, "ID:       %s\nType:     %s\nName:     %s\nContent:  %s\nTTL:      %s\nProxied:  %t\n",
+		rec.ID, rec.Type, rec.Name, recordContent(*rec), ttlString(rec.TTL), rec.Proxied)
+	if rec.Priority != nil {
+		fmt.Fprintf(w, "Priority: %d\n", *rec.Priority)
+	}
+	if rec.Comment != "" {
+		fmt.Fprintf(w, "Comment:  %s\n", rec.Comment)
+	}
+	if len(rec.Tags) > 0 {
+		fmt.Fprintf(w, "Tags:     %s\n", strings.Join(rec.Tags, ", "))
+	}
+	if rec.ModifiedOn != "" {
+		fmt.Fprintf(w, "Modified: %s\n", rec.ModifiedOn)
+	}
+	return nil
+}
+
+// recordContent returns the content of rec, or its data fields as JSON for
+// structured records without content.
+func recordContent(rec cloudflare.DNSRecord) string {
+	if rec.Content != "" || len(rec.Data) == 0 {
+		return rec.Content
+	}
+	b, err := json.Marshal(rec.Data)
+	if err != nil {
+		return fmt.Sprint(rec.Data)
+	}
+	return string(b)
+}
+
+func ttlString(ttl int) string {
+	if ttl == 1 {
+		return "auto"
+	}
+	return fmt.Sprint(ttl)
+}
+
+// flagSet reports whether the named flag was given on the command line.
+func flagSet(fs *flag.FlagSet, name string) bool {
+	found := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			found = true
+		}
+	})
+	return found
+}
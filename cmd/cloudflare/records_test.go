@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+// runTestCLI runs the CLI against the API at url with an API token from the
+// environment and returns the exit code, stdout and stderr.
+func runTestCLI(t *testing.T, url string, args ...string) (int, string, string) {
+	t.Helper()
+	t.Setenv("CF_API_TOKEN", "tok")
+	t.Setenv("CF_EMAIL", "")
+	t.Setenv("CF_GLOBAL_KEY", "")
+	cf := newClientFlags()
+	var stdout, stderr bytes.Buffer
+	cf.stdout, cf.stderr = &stdout, &stderr
+	code := runCLI(cf, append([]string{"-base-url", url, "-retries", "0"}, args...))
+	return code, stdout.String(), stderr.String()
+}
+
+func TestDNSCreate_Data(t *testing.T) {
+	f, srv := newFakeAPI(t)
+	code, stdout, stderr := runTestCLI(t, srv.URL, "dns", "create", "-zone", "example.com", "-type", "CAA", "-name", "@",
+		"-data", `{"flags":0,"tag":"issue","value":"letsencrypt.org"}`, "-output", "json")
+	require.Equal(t, exitOK, code, stderr)
+	require.Contains(t, stdout, `"action":"created"`)
+	require.Equal(t, map[string]any{"flags": float64(0), "tag": "issue", "value": "letsencrypt.org"}, f.records["rec1"].Data)
+	require.Equal(t, "example.com", f.records["rec1"].Name)
+}
+
+func TestDNSCreate_InvalidData(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "not json", args: []string{"-type", "CAA", "-data", `flags=0`}, want: "data must be a JSON object"},
+		{name: "not an object", args: []string{"-type", "CAA", "-data", `[1]`}, want: "data must be a JSON object"},
+		{name: "missing key", args: []string{"-type", "CAA", "-data", `{"flags":0,"tag":"issue"}`}, want: "CAA record: data.value is required"},
+		{name: "content only type", args: []string{"-type", "A", "-data", `{"value":"x"}`}, want: "A record: data is not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, srv := newFakeAPI(t)
+			args := append([]string{"dns", "create", "-zone", "example.com", "-name", "@"}, tt.args...)
+			code, _, stderr := runTestCLI(t, srv.URL, args...)
+			require.Equal(t, exitUsage, code)
+			require.Contains(t, stderr, tt.want)
+			require.Empty(t, f.takeCalls())
+		})
+	}
+}
+
+func TestDNSUpdate_Data(t *testing.T) {
+	srvRec := cloudflare.DNSRecord{Type: "SRV", Name: "_sip._tcp.example.com", TTL: 1,
+		Data: map[string]any{"priority": 10, "weight": 5, "port": 5060, "target": "sip.example.com"}}
+
+	t.Run("valid", func(t *testing.T) {
+		f, srv := newFakeAPI(t, srvRec)
+		code, _, stderr := runTestCLI(t, srv.URL, "dns", "update", "-zone", "example.com", "-type", "SRV", "-name", "_sip._tcp",
+			"-data", `{"priority":10,"weight":5,"port":5061,"target":"sip.example.com"}`)
+		require.Equal(t, exitOK, code, stderr)
+		require.Equal(t, float64(5061), f.records["rec1"].Data["port"])
+		require.Contains(t, f.takeCalls(), "PATCH /zones/zid/dns_records/rec1")
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		f, srv := newFakeAPI(t, srvRec)
+		code, _, stderr := runTestCLI(t, srv.URL, "dns", "update", "-zone", "example.com", "-type", "SRV", "-name", "_sip._tcp",
+			"-data", `{"port":5061}`)
+		require.Equal(t, exitUsage, code)
+		require.Contains(t, stderr, "SRV record: data.priority is required")
+		require.NotContains(t, f.takeCalls(), "PATCH /zones/zid/dns_records/rec1")
+	})
+}
+
+func TestDNSGet_NotFound(t *testing.T) {
+	_, srv := newFakeAPI(t)
+	code, stdout, stderr := runTestCLI(t, srv.URL, "dns", "get", "-zone", "example.com", "-type", "A", "-name", "missing", "-output", "json")
+	require.Equal(t, exitNotFound, code)
+	require.Empty(t, stdout)
+	require.JSONEq(t, `{"error":"no record matches","class":"not_found","exit_code":5}`, stderr)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+)
+
+// zonesCommands maps `cloudflare zones <subcommand>` names to their entry points.
+var zonesCommands = map[string]command{
+	"list": runZonesList,
+	"get":  runZonesGet,
+}
+
+// runZones dispatches the zones subcommands.
+func runZones(cf *clientFlags, args []string) error {
+	return dispatch("zones", zonesCommands, cf, args)
+}
+
+// runZonesList prints the zones visible to the credentials.
+func runZonesList(cf *clientFlags, args []string) error {
+	fs := flag.NewFlagSet("cloudflare zones list", flag.ExitOnError)
+	name := fs.String("name", "", "Only list the zone with this exact name")
+	cf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+	c, err := cf.newClient()
+	if err != nil {
+		return err
+	}
+	zones, err := cloudflare.Collect(c.ListZones(ctx, *name))
+	if err != nil {
+		return err
+	}
+	if zones == nil {
+		zones = []cloudflare.Zone{}
+	}
+	table := func(w io.Writer) error { return writeZoneTable(w, zones...) }
+	return cf.render(view{value: zones, text: table, table: table})
+}
+
+// runZonesGet prints one zone, looked up by name or id.
+func runZonesGet(cf *clientFlags, args []string) error {
+	fs := flag.NewFlagSet("cloudflare zones get", flag.ExitOnError)
+	var (
+		zone = fs.String("zone", envOr("ZONE", ""), "Zone name")
+		id   = fs.String("id", "", "Zone ID (instead of -zone)")
+	)
+	cf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if (strings.TrimSpace(*zone) == "") == (*id == "") {
+		return usagef("exactly one of zone and id is required")
+	}
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+	c, err := cf.newClient()
+	if err != nil {
+		return err
+	}
+	zoneID := *id
+	if zoneID == "" {
+		if zoneID, err = c.FindZoneID(ctx, *zone); err != nil {
+			return err
+		}
+	}
+	z, err := c.GetZone(ctx, zoneID)
+	if err != nil {
+		return err
+	}
+	return cf.render(view{
+		value: z,
+		text: func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, "ID:           %s\nName:         %s\nStatus:       %s\nName servers: %s\n",
+				z.ID, z.Name, z.Status, strings.Join(z.NameServers, ", "))
+			return err
+		},
+		table: func(w io.Writer) error { return writeZoneTable(w, *z) },
+	})
+}
+
+func writeZoneTable(w io.Writer, zones ...cloudflare.Zone) error {
+	rows := make([][]string, 0, len(zones))
+	for _, z := range zones {
+		rows = append(rows, []string{z.ID, z.Name, z.Status})
+	}
+	return writeTable(w, []string{"ID", "NAME", "STATUS"}, rows)
+}
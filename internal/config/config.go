@@ -0,0 +1,204 @@
+// Package config loads the CLI's multi-record configuration file: credential
+// profiles plus the DNS records to keep in sync, in a TOML subset parsed with
+// the standard library only. String values may reference environment
+// variables as ${VAR} or ${VAR:-default} so secrets stay out of the file.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jsirianni/cloudflare-go/cloudflare"
+)
+
+// Record types a configuration entry may manage.
+const (
+	TypeA    = "A"
+	TypeAAAA = "AAAA"
+)
+
+// DefaultProfile is used by records that do not name a profile.
+const DefaultProfile = "default"
+
+// File is a parsed configuration file.
+type File struct {
+	// Profiles maps profile names to credentials.
+	Profiles map[string]Profile
+	// Defaults supplies values for fields records leave unset.
+	Defaults Record
+	// Records lists the DNS records to keep in sync.
+	Records []Record
+}
+
+// Profile holds the credentials of one Cloudflare account: an API token or
+// an email and Global API Key.
+type Profile struct {
+	APIToken  string
+	Email     string
+	GlobalKey string
+}
+
+// Record describes a hostname to keep pointed at the public IP. Pointer
+// fields distinguish unset values from zero values so defaults can apply.
+type Record struct {
+	Profile       string
+	Zone          string
+	Name          string
+	Types         []string
+	TTL           *int
+	Proxied       *bool
+	IPSource      string
+	IPQuorum      *int
+	IPv6Interface string
+	DeleteAAAA    *bool
+}
+
+// Load reads and validates the configuration file at path, expanding
+// environment variables from the process environment.
+func Load(path string) (*File, error) {
+	f, err := os.Open(path) // #nosec G304 -- path is provided by the operator
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	cfg, err := Parse(f, os.LookupEnv)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse decodes and validates a configuration, resolving ${VAR} references
+// with lookup. All problems found are reported together.
+func Parse(r io.Reader, lookup func(string) (string, bool)) (*File, error) {
+	doc, err := parseTOML(r)
+	if err != nil {
+		return nil, err
+	}
+	d := &decoder{lookup: lookup}
+	cfg := d.file(doc)
+	if len(d.errs) > 0 {
+		return nil, errors.Join(d.errs...)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the semantic rules of the configuration.
+func (f *File) Validate() error {
+	var errs []error
+	addf := func(format string, args ...any) { errs = append(errs, fmt.Errorf(format, args...)) }
+
+	for _, name := range sortedKeys(f.Profiles) {
+		p := f.Profiles[name]
+		haveToken := p.APIToken != ""
+		haveKey := p.Email != "" || p.GlobalKey != ""
+		switch {
+		case haveToken && haveKey:
+			addf("profiles.%s: set either api_token or email+global_key, not both", name)
+		case !haveToken && (p.Email == "" || p.GlobalKey == ""):
+			addf("profiles.%s: api_token or both email and global_key are required", name)
+		}
+	}
+	if len(f.Records) == 0 {
+		addf("no [[records]] defined")
+	}
+	seen := map[string]int{}
+	for i, rec := range f.Resolved() {
+		where := fmt.Sprintf("records[%d]", i)
+		if rec.Zone == "" {
+			addf("%s: zone is required", where)
+		}
+		if rec.Name == "" {
+			addf("%s: name is required", where)
+		}
+		if _, ok := f.Profiles[rec.Profile]; !ok {
+			addf("%s: unknown profile %q", where, rec.Profile)
+		}
+		if len(rec.Types) == 0 {
+			addf("%s: types must not be empty", where)
+		}
+		for _, t := range rec.Types {
+			if t != TypeA && t != TypeAAAA {
+				addf("%s: unsupported type %q (want A or AAAA)", where, t)
+			}
+		}
+		if ttl := *rec.TTL; ttl != 1 && (ttl < 30 || ttl > 86400) {
+			addf("%s: ttl must be 1 (auto) or between 30 and 86400", where)
+		}
+		if *rec.IPQuorum < 0 {
+			addf("%s: ip_quorum must be >= 0", where)
+		}
+		if *rec.DeleteAAAA && !slices.Contains(rec.Types, TypeAAAA) {
+			addf("%s: delete_aaaa requires type AAAA", where)
+		}
+		// www and www.example.com, or @ and example.com, name the same record.
+		key := strings.ToLower(cloudflare.FQDN(rec.Name, rec.Zone))
+		if prev, dup := seen[key]; dup {
+			addf("%s: duplicates records[%d] (%s in %s)", where, prev, rec.Name, rec.Zone)
+		}
+		seen[key] = i
+	}
+	return errors.Join(errs...)
+}
+
+// Resolved returns the records with defaults applied: unset fields take the
+// [defaults] value, then built-in defaults (profile "default", type A, TTL 1
+// and not proxied).
+func (f *File) Resolved() []Record {
+	out := make([]Record, len(f.Records))
+	for i, r := range f.Records {
+		d := f.Defaults
+		r.Profile = first(r.Profile, d.Profile, DefaultProfile)
+		r.Zone = first(r.Zone, d.Zone)
+		r.IPSource = first(r.IPSource, d.IPSource)
+		r.IPv6Interface = first(r.IPv6Interface, d.IPv6Interface)
+		if len(r.Types) == 0 {
+			r.Types = d.Types
+		}
+		if len(r.Types) == 0 {
+			r.Types = []string{TypeA}
+		}
+		r.TTL = firstPtr(r.TTL, d.TTL, 1)
+		r.Proxied = firstPtr(r.Proxied, d.Proxied, false)
+		r.IPQuorum = firstPtr(r.IPQuorum, d.IPQuorum, 0)
+		r.DeleteAAAA = firstPtr(r.DeleteAAAA, d.DeleteAAAA, false)
+		out[i] = r
+	}
+	return out
+}
+
+func first(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func firstPtr[T any](v, def *T, builtin T) *T {
+	switch {
+	case v != nil:
+		return v
+	case def != nil:
+		return def
+	}
+	return &builtin
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
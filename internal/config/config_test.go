@@ -0,0 +1,181 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := vars[k]
+		return v, ok
+	}
+}
+
+const sample = `
+[profiles.default]
+api_token = "${CF_API_TOKEN}"
+
+[profiles.work]
+email = "ops@example.org"
+global_key = "${WORK_KEY:-fallback}"
+
+[defaults]
+zone = "example.com"
+ttl = 300
+ip_source = "ipify,icanhazip"
+
+[[records]]
+name = "home"
+types = ["A", "aaaa"]
+proxied = true
+
+[[records]]
+profile = "work"
+zone = "example.org"
+name = "office"
+ttl = 1
+ip_source = "stun"
+`
+
+func TestParse(t *testing.T) {
+	cfg, err := config.Parse(strings.NewReader(sample), env(map[string]string{"CF_API_TOKEN": "secret"}))
+	require.NoError(t, err)
+	require.Equal(t, config.Profile{APIToken: "secret"}, cfg.Profiles["default"])
+	require.Equal(t, config.Profile{Email: "ops@example.org", GlobalKey: "fallback"}, cfg.Profiles["work"])
+
+	recs := cfg.Resolved()
+	require.Len(t, recs, 2)
+
+	home := recs[0]
+	require.Equal(t, "default", home.Profile)
+	require.Equal(t, "example.com", home.Zone)
+	require.Equal(t, []string{"A", "AAAA"}, home.Types)
+	require.Equal(t, 300, *home.TTL)
+	require.True(t, *home.Proxied)
+	require.Equal(t, "ipify,icanhazip", home.IPSource)
+	require.False(t, *home.DeleteAAAA)
+
+	office := recs[1]
+	require.Equal(t, "work", office.Profile)
+	require.Equal(t, "example.org", office.Zone)
+	require.Equal(t, []string{"A"}, office.Types)
+	require.Equal(t, 1, *office.TTL)
+	require.False(t, *office.Proxied)
+	require.Equal(t, "stun", office.IPSource)
+}
+
+func TestParse_Errors(t *testing.T) {
+	cases := map[string]struct {
+		input string
+		want  []string
+	}{
+		"unknown keys": {
+			input: "tll = 1\n[profiles.default]\ntoken = \"x\"\n[[records]]\nzone = \"example.com\"\nname = \"www\"\ntll = 60\n",
+			want:  []string{`unknown top-level key "tll"`, "profiles.default.token: unknown key", "records[0].tll: unknown key"},
+		},
+		"wrong types": {
+			input: "[profiles.default]\napi_token = \"x\"\n[[records]]\nzone = \"example.com\"\nname = \"www\"\nttl = \"60\"\nproxied = 1\ntypes = \"A\"\n",
+			want:  []string{"records[0].ttl: must be an integer", "records[0].proxied: must be true or false", "records[0].types: must be an array of strings"},
+		},
+		"missing env": {
+			input: "[profiles.default]\napi_token = \"${NOPE}\"\n[[records]]\nzone = \"example.com\"\nname = \"www\"\n",
+			want:  []string{"profiles.default.api_token: environment variable NOPE is not set"},
+		},
+		"semantic": {
+			input: `
+[profiles.default]
+api_token = "x"
+email = "a@example.com"
+global_key = "k"
+
+[[records]]
+name = "www"
+types = ["MX"]
+ttl = 5
+profile = "missing"
+
+[[records]]
+zone = "example.com"
+name = "api"
+delete_aaaa = true
+
+[[records]]
+zone = "EXAMPLE.com"
+name = "api"
+types = ["AAAA"]
+
+[[records]]
+zone = "example.com"
+name = "api.example.com."
+
+[[records]]
+zone = "example.com"
+name = "@"
+
+[[records]]
+zone = "example.com"
+name = "Example.com"
+`,
+			want: []string{
+				"profiles.default: set either api_token or email+global_key, not both",
+				"records[0]: zone is required",
+				`records[0]: unknown profile "missing"`,
+				`records[0]: unsupported type "MX"`,
+				"records[0]: ttl must be 1 (auto) or between 30 and 86400",
+				"records[1]: delete_aaaa requires type AAAA",
+				"records[2]: duplicates records[1]",
+				"records[3]: duplicates records[2]",
+				"records[5]: duplicates records[4]",
+			},
+		},
+		"no records": {
+			input: "[profiles.default]\napi_token = \"x\"\n",
+			want:  []string{"no [[records]] defined"},
+		},
+		"syntax": {
+			input: "[profiles.default\n",
+			want:  []string{"line 1: malformed table header"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := config.Parse(strings.NewReader(tc.input), env(nil))
+			require.Error(t, err)
+			for _, w := range tc.want {
+				require.Contains(t, err.Error(), w)
+			}
+		})
+	}
+}
+
+func TestParse_EnvInterpolation(t *testing.T) {
+	input := `
+[profiles.default]
+api_token = "pre-${TOKEN}-$${LITERAL}-${EMPTY:-dflt}-${SET_EMPTY}"
+
+[[records]]
+zone = "example.com"
+name = "www"
+`
+	cfg, err := config.Parse(strings.NewReader(input), env(map[string]string{"TOKEN": "t", "SET_EMPTY": ""}))
+	require.NoError(t, err)
+	require.Equal(t, "pre-t-${LITERAL}-dflt-", cfg.Profiles["default"].APIToken)
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cloudflare.toml")
+	require.NoError(t, os.WriteFile(path, []byte("[[records]]\nname = \"www\"\n"), 0o600))
+	_, err := config.Load(path)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), path)
+	require.Contains(t, err.Error(), "zone is required")
+
+	_, err = config.Load(filepath.Join(t.TempDir(), "missing.toml"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// decoder converts the generic TOML document into a File, collecting every
+// unknown key, type mismatch and unresolved variable instead of stopping at
+// the first.
+type decoder struct {
+	lookup func(string) (string, bool)
+	errs   []error
+}
+
+func (d *decoder) errorf(format string, args ...any) {
+	d.errs = append(d.errs, fmt.Errorf(format, args...))
+}
+
+func (d *decoder) file(doc map[string]any) *File {
+	f := &File{Profiles: map[string]Profile{}}
+	for _, key := range sortedKeys(doc) {
+		switch v := doc[key]; key {
+		case "profiles":
+			profiles, ok := v.(map[string]any)
+			if !ok {
+				d.errorf("profiles: must be a table of [profiles.NAME] tables")
+				continue
+			}
+			for _, name := range sortedKeys(profiles) {
+				t, ok := profiles[name].(map[string]any)
+				if !ok {
+					d.errorf("profiles.%s: must be a table", name)
+					continue
+				}
+				f.Profiles[name] = d.profile("profiles."+name, t)
+			}
+		case "defaults":
+			t, ok := v.(map[string]any)
+			if !ok {
+				d.errorf("defaults: must be a table")
+				continue
+			}
+			f.Defaults = d.record("defaults", t)
+		case "records":
+			list, ok := v.([]map[string]any)
+			if !ok {
+				d.errorf("records: must be an array of [[records]] tables")
+				continue
+			}
+			for i, t := range list {
+				f.Records = append(f.Records, d.record(fmt.Sprintf("records[%d]", i), t))
+			}
+		default:
+			d.errorf("unknown top-level key %q", key)
+		}
+	}
+	return f
+}
+
+func (d *decoder) profile(where string, t map[string]any) Profile {
+	var p Profile
+	for _, key := range sortedKeys(t) {
+		path := where + "." + key
+		switch key {
+		case "api_token":
+			p.APIToken = d.str(path, t[key])
+		case "email":
+			p.Email = d.str(path, t[key])
+		case "global_key":
+			p.GlobalKey = d.str(path, t[key])
+		default:
+			d.errorf("%s: unknown key", path)
+		}
+	}
+	return p
+}
+
+func (d *decoder) record(where string, t map[string]any) Record {
+	var r Record
+	for _, key := range sortedKeys(t) {
+		path := where + "." + key
+		v := t[key]
+		switch key {
+		case "profile":
+			r.Profile = d.str(path, v)
+		case "zone":
+			r.Zone = d.str(path, v)
+		case "name":
+			r.Name = d.str(path, v)
+		case "types":
+			r.Types = d.strs(path, v)
+			for i, typ := range r.Types {
+				r.Types[i] = strings.ToUpper(typ)
+			}
+		case "ttl":
+			r.TTL = d.int(path, v)
+		case "proxied":
+			r.Proxied = d.bool(path, v)
+		case "ip_source":
+			r.IPSource = d.str(path, v)
+		case "ip_quorum":
+			r.IPQuorum = d.int(path, v)
+		case "ipv6_interface":
+			r.IPv6Interface = d.str(path, v)
+		case "delete_aaaa":
+			r.DeleteAAAA = d.bool(path, v)
+		default:
+			d.errorf("%s: unknown key", path)
+		}
+	}
+	return r
+}
+
+func (d *decoder) str(path string, v any) string {
+	s, ok := v.(string)
+	if !ok {
+		d.errorf("%s: must be a string", path)
+		return ""
+	}
+	out, err := expandEnv(s, d.lookup)
+	if err != nil {
+		d.errorf("%s: %v", path, err)
+	}
+	return out
+}
+
+func (d *decoder) strs(path string, v any) []string {
+	list, ok := v.([]any)
+	if !ok {
+		d.errorf("%s: must be an array of strings", path)
+		return nil
+	}
+	out := make([]string, 0, len(list))
+	for i, item := range list {
+		out = append(out, d.str(fmt.Sprintf("%s[%d]", path, i), item))
+	}
+	return out
+}
+
+func (d *decoder) int(path string, v any) *int {
+	n, ok := v.(int64)
+	if !ok {
+		d.errorf("%s: must be an integer", path)
+		return nil
+	}
+	i := int(n)
+	return &i
+}
+
+func (d *decoder) bool(path string, v any) *bool {
+	b, ok := v.(bool)
+	if !ok {
+		d.errorf("%s: must be true or false", path)
+		return nil
+	}
+	return &b
+}
+
+// expandEnv replaces ${VAR} and ${VAR:-default} references; "$$" is a
+// literal dollar sign. Referencing an unset variable without a default is an
+// error so a missing secret is caught before any request is made.
+func expandEnv(s string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch {
+		case strings.HasPrefix(s[i:], "$$"):
+			b.WriteByte('$')
+			i++
+		case strings.HasPrefix(s[i:], "${"):
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated ${ in %q", s)
+			}
+			expr := s[i+2 : i+end]
+			name, def, hasDef := strings.Cut(expr, ":-")
+			if name == "" {
+				return "", fmt.Errorf("empty variable name in %q", s)
+			}
+			val, ok := lookup(name)
+			switch {
+			case ok && val != "":
+				b.WriteString(val)
+			case hasDef:
+				b.WriteString(def)
+			case ok:
+			default:
+				return "", fmt.Errorf("environment variable %s is not set", name)
+			}
+			i += end
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,342 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseTOML parses the subset of TOML used by configuration files: comments,
+// [tables] and [[arrays of tables]] with dotted bare keys, and key = value
+// pairs whose values are strings (basic and literal), integers, booleans or
+// arrays of those, which may span lines. Tables decode to map[string]any and
+// arrays of tables to []map[string]any.
+func parseTOML(r io.Reader) (map[string]any, error) {
+	p := &tomlParser{root: map[string]any{}}
+	p.cur = p.root
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	var (
+		pending   strings.Builder // array value spanning lines
+		startLine int
+	)
+	for line := 1; sc.Scan(); line++ {
+		text := stripComment(sc.Text())
+		if pending.Len() > 0 {
+			pending.WriteString("\n")
+			pending.WriteString(text)
+			if !arrayComplete(pending.String()) {
+				continue
+			}
+			text = pending.String()
+			pending.Reset()
+		} else {
+			startLine = line
+			if _, value, ok := strings.Cut(text, "="); ok && !isHeader(text) && !arrayComplete(value) {
+				pending.WriteString(text)
+				continue
+			}
+		}
+		if err := p.line(text); err != nil {
+			return nil, fmt.Errorf("line %d: %w", startLine, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if pending.Len() > 0 {
+		return nil, fmt.Errorf("line %d: unterminated array", startLine)
+	}
+	return p.root, nil
+}
+
+type tomlParser struct {
+	root map[string]any
+	cur  map[string]any
+	// defined tracks explicitly declared [tables] to reject duplicates.
+	defined map[string]bool
+}
+
+func (p *tomlParser) line(text string) error {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == "":
+		return nil
+	case strings.HasPrefix(text, "[["):
+		if !strings.HasSuffix(text, "]]") {
+			return fmt.Errorf("malformed array of tables header %q", text)
+		}
+		return p.arrayTable(strings.TrimSpace(text[2 : len(text)-2]))
+	case strings.HasPrefix(text, "["):
+		if !strings.HasSuffix(text, "]") {
+			return fmt.Errorf("malformed table header %q", text)
+		}
+		return p.table(strings.TrimSpace(text[1 : len(text)-1]))
+	}
+	key, raw, ok := strings.Cut(text, "=")
+	if !ok {
+		return fmt.Errorf("expected key = value, got %q", text)
+	}
+	key = strings.TrimSpace(key)
+	if !isBareKey(key) {
+		return fmt.Errorf("invalid key %q", key)
+	}
+	if _, dup := p.cur[key]; dup {
+		return fmt.Errorf("duplicate key %q", key)
+	}
+	v, rest, err := parseValue(strings.TrimSpace(raw))
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	if strings.TrimSpace(rest) != "" {
+		return fmt.Errorf("%s: unexpected %q after value", key, rest)
+	}
+	p.cur[key] = v
+	return nil
+}
+
+// table selects (creating as needed) the table at a dotted path.
+func (p *tomlParser) table(path string) error {
+	if p.defined == nil {
+		p.defined = map[string]bool{}
+	}
+	if p.defined[path] {
+		return fmt.Errorf("table [%s] defined twice", path)
+	}
+	p.defined[path] = true
+	t, err := p.walk(path)
+	if err != nil {
+		return err
+	}
+	p.cur = t
+	return nil
+}
+
+// arrayTable appends a new table to the array at a dotted path.
+func (p *tomlParser) arrayTable(path string) error {
+	keys, err := splitKey(path)
+	if err != nil {
+		return err
+	}
+	parent, err := p.walk(strings.Join(keys[:len(keys)-1], "."))
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	t := map[string]any{}
+	switch existing := parent[last].(type) {
+	case nil:
+		parent[last] = []map[string]any{t}
+	case []map[string]any:
+		parent[last] = append(existing, t)
+	default:
+		return fmt.Errorf("key %q is not an array of tables", path)
+	}
+	p.cur = t
+	return nil
+}
+
+// walk descends from the root along a dotted path, creating tables. A
+// segment naming an array of tables descends into its last element.
+func (p *tomlParser) walk(path string) (map[string]any, error) {
+	t := p.root
+	if path == "" {
+		return t, nil
+	}
+	keys, err := splitKey(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		switch next := t[k].(type) {
+		case nil:
+			m := map[string]any{}
+			t[k] = m
+			t = m
+		case map[string]any:
+			t = next
+		case []map[string]any:
+			t = next[len(next)-1]
+		default:
+			return nil, fmt.Errorf("key %q is not a table", k)
+		}
+	}
+	return t, nil
+}
+
+func splitKey(path string) ([]string, error) {
+	keys := strings.Split(path, ".")
+	for i, k := range keys {
+		keys[i] = strings.TrimSpace(k)
+		if !isBareKey(keys[i]) {
+			return nil, fmt.Errorf("invalid key %q", path)
+		}
+	}
+	return keys, nil
+}
+
+func isBareKey(k string) bool {
+	if k == "" {
+		return false
+	}
+	for _, r := range k {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+func isHeader(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), "[")
+}
+
+// parseValue parses one value from the start of s and returns the rest.
+func parseValue(s string) (any, string, error) {
+	switch {
+	case s == "":
+		return nil, "", fmt.Errorf("missing value")
+	case s[0] == '"':
+		return parseBasicString(s)
+	case s[0] == '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return nil, "", fmt.Errorf("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	case s[0] == '[':
+		return parseArray(s[1:])
+	}
+	end := strings.IndexAny(s, ",] \t\n")
+	if end < 0 {
+		end = len(s)
+	}
+	word, rest := s[:end], s[end:]
+	switch word {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(word, "_", ""), 10, 64)
+	if err != nil {
+		return nil, "", fmt.Errorf("unsupported value %q (strings must be quoted)", word)
+	}
+	return n, rest, nil
+}
+
+func parseArray(s string) (any, string, error) {
+	out := []any{}
+	for {
+		s = strings.TrimLeft(s, " \t\n\r")
+		if strings.HasPrefix(s, "]") {
+			return out, s[1:], nil
+		}
+		v, rest, err := parseValue(s)
+		if err != nil {
+			return nil, "", err
+		}
+		out = append(out, v)
+		s = strings.TrimLeft(rest, " \t\n\r")
+		switch {
+		case strings.HasPrefix(s, ","):
+			s = s[1:]
+		case strings.HasPrefix(s, "]"):
+		default:
+			return nil, "", fmt.Errorf("expected , or ] in array")
+		}
+	}
+}
+
+func parseBasicString(s string) (string, string, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return b.String(), s[i+1:], nil
+		case '\\':
+			if i+1 >= len(s) {
+				return "", "", fmt.Errorf("unterminated string")
+			}
+			i++
+			switch s[i] {
+			case '"', '\\':
+				b.WriteByte(s[i])
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'u', 'U':
+				size := 4
+				if s[i] == 'U' {
+					size = 8
+				}
+				if i+size >= len(s) {
+					return "", "", fmt.Errorf("invalid unicode escape")
+				}
+				n, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+				if err != nil || !utf8.ValidRune(rune(n)) {
+					return "", "", fmt.Errorf("invalid unicode escape")
+				}
+				b.WriteRune(rune(n))
+				i += size
+			default:
+				return "", "", fmt.Errorf("invalid escape \\%c", s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string")
+}
+
+// stripComment removes a # comment that is not inside a string.
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// arrayComplete reports whether the brackets of a comment-free value are
+// balanced, i.e. a multi-line array has been fully read.
+func arrayComplete(value string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		}
+	}
+	return depth <= 0
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTOML(t *testing.T) {
+	doc, err := parseTOML(strings.NewReader(`
+# leading comment
+title = "a \"quoted\" # not a comment" # trailing comment
+path = 'C:\raw'
+count = 1_000
+enabled = true
+
+[profiles.home]
+api_token = "tok"
+
+[[records]]
+name = "www"
+types = [
+  "A",   # IPv4
+  "AAAA",
+]
+
+[[records]]
+name = "vpn"
+types = []
+`))
+	require.NoError(t, err)
+	require.Equal(t, `a "quoted" # not a comment`, doc["title"])
+	require.Equal(t, `C:\raw`, doc["path"])
+	require.Equal(t, int64(1000), doc["count"])
+	require.Equal(t, true, doc["enabled"])
+	require.Equal(t, map[string]any{"home": map[string]any{"api_token": "tok"}}, doc["profiles"])
+	require.Equal(t, []map[string]any{
+		{"name": "www", "types": []any{"A", "AAAA"}},
+		{"name": "vpn", "types": []any{}},
+	}, doc["records"])
+}
+
+func TestParseTOML_Errors(t *testing.T) {
+	cases := map[string]string{
+		"duplicate key":     "a = 1\na = 2\n",
+		"duplicate table":   "[x]\n[x]\n",
+		"missing value":     "a =\n",
+		"unterminated":      "a = \"abc\n",
+		"unterminated list": "a = [1,\n2\n",
+		"bad header":        "[x\n",
+		"bare value":        "a = yes\n",
+		"no equals":         "just text\n",
+	}
+	for name, input := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseTOML(strings.NewReader(input))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "line ")
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/internal/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	r := metrics.NewRegistry()
+	updates := r.NewCounter("ddns_updates_total", "Record updates by action.", "action")
+	ts := r.NewGauge("ddns_last_success_timestamp_seconds", "Last successful sync.\nUnix time.")
+	info := r.NewGauge("ddns_ip_info", "Last discovered address.", "family", "ip")
+	lat := r.NewHistogram("discovery_duration_seconds", "Discovery latency.", []float64{0.1, 1}, "source")
+
+	updates.Inc("updated")
+	updates.Add(2, "created")
+	updates.Inc("updated")
+	ts.Set(1.7e9)
+	info.Set(1, "ipv4", `203.0.113.7`)
+	info.Set(1, "ipv6", "a\"b\\c\nd")
+	lat.Observe(0.05, "ipify")
+	lat.Observe(0.1, "ipify")
+	lat.Observe(3, "ipify")
+
+	var b strings.Builder
+	require.NoError(t, r.WriteText(&b))
+	require.Equal(t, `# HELP ddns_updates_total Record updates by action.
+# TYPE ddns_updates_total counter
+ddns_updates_total{action="created"} 2
+ddns_updates_total{action="updated"} 2
+# HELP ddns_last_success_timestamp_seconds Last successful sync.\nUnix time.
+# TYPE ddns_last_success_timestamp_seconds gauge
+ddns_last_success_timestamp_seconds 1.7e+09
+# HELP ddns_ip_info Last discovered address.
+# TYPE ddns_ip_info gauge
+ddns_ip_info{family="ipv4",ip="203.0.113.7"} 1
+ddns_ip_info{family="ipv6",ip="a\"b\\c\nd"} 1
+# HELP discovery_duration_seconds Discovery latency.
+# TYPE discovery_duration_seconds histogram
+discovery_duration_seconds_bucket{source="ipify",le="0.1"} 2
+discovery_duration_seconds_bucket{source="ipify",le="1"} 2
+discovery_duration_seconds_bucket{source="ipify",le="+Inf"} 3
+discovery_duration_seconds_sum{source="ipify"} 3.15
+discovery_duration_seconds_count{source="ipify"} 3
+`, b.String())
+}
+
+func TestGauge_Delete(t *testing.T) {
+	r := metrics.NewRegistry()
+	info := r.NewGauge("ip_info", "help", "family", "ip")
+	info.Set(1, "ipv4", "203.0.113.1")
+	info.Set(1, "ipv6", "2001:db8::1")
+	info.Delete("ipv4", "203.0.113.1")
+	info.Set(1, "ipv4", "203.0.113.2")
+	info.Delete("ipv6", "2001:db8::1")
+
+	var b strings.Builder
+	require.NoError(t, r.WriteText(&b))
+	require.NotContains(t, b.String(), "203.0.113.1")
+	require.NotContains(t, b.String(), "2001:db8::1")
+	require.Contains(t, b.String(), `ip_info{family="ipv4",ip="203.0.113.2"} 1`)
+}
+
+func TestRegistry_Misuse(t *testing.T) {
+	r := metrics.NewRegistry()
+	c := r.NewCounter("c_total", "help", "a")
+	require.Panics(t, func() { r.NewGauge("c_total", "dup") })
+	require.Panics(t, func() { c.Inc() })
+	require.Panics(t, func() { c.Add(-1, "x") })
+	require.Panics(t, func() { r.NewHistogram("h", "help", []float64{1, 0.5}) })
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.NewCounter("requests_total", "help").Inc()
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, metrics.ContentType, rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Body.String(), "requests_total 1\n")
+}
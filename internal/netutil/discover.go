@@ -0,0 +1,263 @@
+package netutil
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Family selects the IP address family to discover.
+type Family int
+
+// Address families.
+const (
+	IPv4 Family = 4
+	IPv6 Family = 6
+)
+
+// String implements fmt.Stringer.
+func (f Family) String() string {
+	switch f {
+	case IPv4:
+		return "IPv4"
+	case IPv6:
+		return "IPv6"
+	}
+	return fmt.Sprintf("Family(%d)", int(f))
+}
+
+// ErrUnsupportedFamily is returned by discoverers that cannot look up the
+// requested address family.
+var ErrUnsupportedFamily = errors.New("address family not supported by this source")
+
+// IPDiscoverer discovers the host's public IP address.
+type IPDiscoverer interface {
+	// Name identifies the source in logs and errors.
+	Name() string
+	// DiscoverIP returns the public address of the given family in canonical
+	// text form.
+	DiscoverIP(ctx context.Context, family Family) (string, error)
+}
+
+// HTTPDiscoverer queries an HTTP echo service that reports the caller's
+// address. A zero URL for a family makes that family unsupported.
+type HTTPDiscoverer struct {
+	// Label is returned by Name.
+	Label string
+	// URLv4 and URLv6 are the endpoints queried for each family.
+	URLv4 string
+	URLv6 string
+	// Client performs the requests. When nil, a client with a 10s timeout is
+	// used whose dialer is pinned to the requested family.
+	Client *http.Client
+	// Parse extracts the address from the response body. When nil the body
+	// must be the bare address.
+	Parse func(body []byte) (string, error)
+}
+
+// NewIpify returns a discoverer for api.ipify.org and api6.ipify.org.
+func NewIpify(client *http.Client) *HTTPDiscoverer {
+	return &HTTPDiscoverer{Label: "ipify", URLv4: "https://api.ipify.org", URLv6: "https://api6.ipify.org", Client: client}
+}
+
+// NewIcanhazip returns a discoverer for ipv4.icanhazip.com and ipv6.icanhazip.com.
+func NewIcanhazip(client *http.Client) *HTTPDiscoverer {
+	return &HTTPDiscoverer{Label: "icanhazip", URLv4: "https://ipv4.icanhazip.com", URLv6: "https://ipv6.icanhazip.com", Client: client}
+}
+
+// NewCloudflareTrace returns a discoverer for Cloudflare's /cdn-cgi/trace
+// endpoint on the 1.1.1.1 resolver addresses, parsing its "ip=" line.
+func NewCloudflareTrace(client *http.Client) *HTTPDiscoverer {
+	return &HTTPDiscoverer{
+		Label:  "cloudflare",
+		URLv4:  "https://1.1.1.1/cdn-cgi/trace",
+		URLv6:  "https://[2606:4700:4700::1111]/cdn-cgi/trace",
+		Client: client,
+		Parse:  ParseTrace,
+	}
+}
+
+// NewURLDiscoverer returns a discoverer for a custom echo URL that answers
+// with the bare address. The same URL is used for both families; without a
+// custom client the connection is forced onto the requested family.
+func NewURLDiscoverer(url string, client *http.Client) *HTTPDiscoverer {
+	return &HTTPDiscoverer{Label: url, URLv4: url, URLv6: url, Client: client}
+}
+
+// Name implements IPDiscoverer.
+func (d *HTTPDiscoverer) Name() string { return d.Label }
+
+// DiscoverIP implements IPDiscoverer. For IPv6, errors caused by the host
+// lacking an IPv6 route wrap ErrNoIPv6.
+func (d *HTTPDiscoverer) DiscoverIP(ctx context.Context, family Family) (string, error) {
+	url := d.URLv4
+	if family == IPv6 {
+		url = d.URLv6
+	}
+	if url == "" || (family != IPv4 && family != IPv6) {
+		return "", fmt.Errorf("%s: %w: %s", d.Label, ErrUnsupportedFamily, family)
+	}
+	client := d.Client
+	if client == nil {
+		client = familyClient(family)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if family == IPv6 && isNoRoute(err) {
+			return "", fmt.Errorf("%w: %w", ErrNoIPv6, err)
+		}
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", errors.New(resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxEchoBody))
+	if err != nil {
+		return "", err
+	}
+	text := strings.TrimSpace(string(b))
+	if d.Parse != nil {
+		if text, err = d.Parse(b); err != nil {
+			return "", err
+		}
+	}
+	return parseIP(text, family == IPv6)
+}
+
+// familyClient returns an HTTP client whose connections use only the given
+// address family, so a dual-stack echo service reports the wanted address.
+func familyClient(family Family) *http.Client {
+	network := "tcp4"
+	if family == IPv6 {
+		network = "tcp6"
+	}
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return &http.Client{Timeout: 10 * time.Second, Transport: tr}
+}
+
+// ParseTrace extracts the value of the "ip=" line from a Cloudflare
+// /cdn-cgi/trace response.
+func ParseTrace(body []byte) (string, error) {
+	sc := bufio.NewScanner(bytes.NewReader(body))
+	for sc.Scan() {
+		if ip, ok := strings.CutPrefix(strings.TrimSpace(sc.Text()), "ip="); ok {
+			return ip, nil
+		}
+	}
+	return "", errors.New("trace response has no ip= line")
+}
+
+// Quorum queries several discoverers concurrently and returns the address
+// reported by at least Min of them. It fails rather than guessing when the
+// sources disagree, guarding against a single faulty or hijacked service.
+type Quorum struct {
+	Sources []IPDiscoverer
+	// Min is the number of sources that must agree. Zero means a majority.
+	Min int
+}
+
+// NewQuorum returns a Quorum requiring min of the sources to agree.
+func NewQuorum(min int, sources ...IPDiscoverer) *Quorum {
+	return &Quorum{Sources: sources, Min: min}
+}
+
+// Name implements IPDiscoverer.
+func (q *Quorum) Name() string {
+	names := make([]string, len(q.Sources))
+	for i, s := range q.Sources {
+		names[i] = s.Name()
+	}
+	return fmt.Sprintf("quorum(%d of %s)", q.required(), strings.Join(names, ","))
+}
+
+func (q *Quorum) required() int {
+	if q.Min > 0 {
+		return q.Min
+	}
+	return len(q.Sources)/2 + 1
+}
+
+// DiscoverIP implements IPDiscoverer. It returns as soon as enough sources
+// agree, canceling the rest, or as soon as agreement becomes impossible.
+// The error wraps ErrNoIPv6 only if every source reported missing IPv6.
+func (q *Quorum) DiscoverIP(ctx context.Context, family Family) (string, error) {
+	need := q.required()
+	if len(q.Sources) == 0 || need > len(q.Sources) {
+		return "", fmt.Errorf("quorum of %d needs at least that many sources, have %d", need, len(q.Sources))
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		name string
+		ip   string
+		err  error
+	}
+	results := make(chan result, len(q.Sources))
+	for _, s := range q.Sources {
+		go func() {
+			ip, err := s.DiscoverIP(ctx, family)
+			results <- result{name: s.Name(), ip: ip, err: err}
+		}()
+	}
+
+	votes := make(map[string]int)
+	var (
+		failures []string
+		absent   int
+		best     int
+	)
+	for pending := len(q.Sources); pending > 0; {
+		r := <-results
+		pending--
+		if r.err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", r.name, r.err))
+			if errors.Is(r.err, ErrNoIPv6) || errors.Is(r.err, ErrUnsupportedFamily) {
+				absent++
+			}
+		} else {
+			votes[r.ip]++
+			if votes[r.ip] >= need {
+				return r.ip, nil
+			}
+			best = max(best, votes[r.ip])
+		}
+		if best+pending < need {
+			break
+		}
+	}
+	if err := ctx.Err(); err != nil && len(votes) == 0 {
+		return "", err
+	}
+	if family == IPv6 && len(votes) == 0 && absent == len(failures) && len(failures) == len(q.Sources) {
+		return "", fmt.Errorf("%w: %s", ErrNoIPv6, strings.Join(failures, "; "))
+	}
+	return "", fmt.Errorf("no %s address agreed on by %d of %d sources (%s)", family, need, len(q.Sources), describeVotes(votes, failures))
+}
+
+// describeVotes summarizes quorum results for an error message.
+func describeVotes(votes map[string]int, failures []string) string {
+	parts := make([]string, 0, len(votes)+len(failures))
+	for ip, n := range votes {
+		parts = append(parts, fmt.Sprintf("%s x%d", ip, n))
+	}
+	sort.Strings(parts)
+	return strings.Join(append(parts, failures...), "; ")
+}
@@ -0,0 +1,124 @@
+package netutil_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jsirianni/cloudflare-go/internal/netutil"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedSource is an IPDiscoverer returning a canned answer after delay.
+type fixedSource struct {
+	name  string
+	ip    string
+	err   error
+	delay time.Duration
+}
+
+func (f fixedSource) Name() string { return f.name }
+
+func (f fixedSource) DiscoverIP(ctx context.Context, _ netutil.Family) (string, error) {
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	return f.ip, f.err
+}
+
+func TestHTTPDiscoverer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cdn-cgi/trace":
+			fmt.Fprint(w, "fl=123\nh=1.1.1.1\nip=203.0.113.7\nts=1700000000.1\n")
+		case "/v6":
+			fmt.Fprint(w, "2001:db8::7\n")
+		default:
+			fmt.Fprint(w, "203.0.113.8")
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	trace := netutil.NewCloudflareTrace(srv.Client())
+	trace.URLv4 = srv.URL + "/cdn-cgi/trace"
+	ip, err := trace.DiscoverIP(ctx, netutil.IPv4)
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.7", ip)
+
+	custom := netutil.NewURLDiscoverer(srv.URL+"/plain", srv.Client())
+	ip, err = custom.DiscoverIP(ctx, netutil.IPv4)
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.8", ip)
+	_, err = custom.DiscoverIP(ctx, netutil.IPv6)
+	require.ErrorContains(t, err, "invalid IPv6 response")
+
+	v6 := &netutil.HTTPDiscoverer{Label: "v6only", URLv6: srv.URL + "/v6", Client: srv.Client()}
+	ip, err = v6.DiscoverIP(ctx, netutil.IPv6)
+	require.NoError(t, err)
+	require.Equal(t, "2001:db8::7", ip)
+	_, err = v6.DiscoverIP(ctx, netutil.IPv4)
+	require.ErrorIs(t, err, netutil.ErrUnsupportedFamily)
+}
+
+func TestParseTrace(t *testing.T) {
+	ip, err := netutil.ParseTrace([]byte("h=one.one.one.one\nip=2001:db8::1\nloc=US\n"))
+	require.NoError(t, err)
+	require.Equal(t, "2001:db8::1", ip)
+
+	_, err = netutil.ParseTrace([]byte("h=x\n"))
+	require.Error(t, err)
+}
+
+func TestQuorum(t *testing.T) {
+	ctx := context.Background()
+	good := func(name string) fixedSource { return fixedSource{name: name, ip: "203.0.113.1"} }
+
+	t.Run("majority agrees", func(t *testing.T) {
+		q := netutil.NewQuorum(0, good("a"), good("b"), fixedSource{name: "evil", ip: "198.51.100.66"})
+		ip, err := q.DiscoverIP(ctx, netutil.IPv4)
+		require.NoError(t, err)
+		require.Equal(t, "203.0.113.1", ip)
+	})
+
+	t.Run("returns without waiting for slow sources", func(t *testing.T) {
+		q := netutil.NewQuorum(2, good("a"), good("b"), fixedSource{name: "slow", ip: "203.0.113.1", delay: time.Minute})
+		start := time.Now()
+		_, err := q.DiscoverIP(ctx, netutil.IPv4)
+		require.NoError(t, err)
+		require.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("disagreement fails", func(t *testing.T) {
+		q := netutil.NewQuorum(2, good("a"), fixedSource{name: "b", ip: "198.51.100.2"}, fixedSource{name: "c", err: errors.New("down")})
+		_, err := q.DiscoverIP(ctx, netutil.IPv4)
+		require.ErrorContains(t, err, "no IPv4 address agreed on by 2 of 3 sources")
+		require.ErrorContains(t, err, "c: down")
+	})
+
+	t.Run("all sources lack IPv6", func(t *testing.T) {
+		q := netutil.NewQuorum(1,
+			fixedSource{name: "a", err: netutil.ErrNoIPv6},
+			fixedSource{name: "b", err: netutil.ErrUnsupportedFamily})
+		_, err := q.DiscoverIP(ctx, netutil.IPv6)
+		require.ErrorIs(t, err, netutil.ErrNoIPv6)
+	})
+
+	t.Run("partial IPv6 failure is not missing IPv6", func(t *testing.T) {
+		q := netutil.NewQuorum(2, fixedSource{name: "a", err: netutil.ErrNoIPv6}, fixedSource{name: "b", ip: "2001:db8::1"})
+		_, err := q.DiscoverIP(ctx, netutil.IPv6)
+		require.Error(t, err)
+		require.NotErrorIs(t, err, netutil.ErrNoIPv6)
+	})
+
+	t.Run("min larger than sources", func(t *testing.T) {
+		_, err := netutil.NewQuorum(3, good("a")).DiscoverIP(ctx, netutil.IPv4)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,173 @@
+package netutil
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"time"
+)
+
+// DNSDiscoverer learns the public address by asking a resolver that reports
+// the client's address in its answer, so it works where only DNS egress is
+// allowed. Queries go over UDP and fall back to TCP when truncated.
+type DNSDiscoverer struct {
+	// Label is returned by Name.
+	Label string
+	// ServerV4 and ServerV6 are the resolvers (host:port) queried for each
+	// family; the answer reflects the address used to reach them. An empty
+	// server makes that family unsupported.
+	ServerV4 string
+	ServerV6 string
+	// QueryName is the name to look up.
+	QueryName string
+	// TXT selects a CHAOS-class TXT query whose text is the address (as with
+	// whoami.cloudflare). Otherwise an IN-class A or AAAA query is sent.
+	TXT bool
+	// Timeout bounds each UDP attempt; zero means 2s.
+	Timeout time.Duration
+	// Attempts is the number of UDP transmissions; zero means 2.
+	Attempts int
+}
+
+// NewCloudflareDNS returns a discoverer querying whoami.cloudflare TXT CH on
+// 1.1.1.1 and 2606:4700:4700::1111.
+func NewCloudflareDNS() *DNSDiscoverer {
+	return &DNSDiscoverer{
+		Label:     "dns:cloudflare",
+		ServerV4:  "1.1.1.1:53",
+		ServerV6:  "[2606:4700:4700::1111]:53",
+		QueryName: "whoami.cloudflare",
+		TXT:       true,
+	}
+}
+
+// NewOpenDNS returns a discoverer querying myip.opendns.com on
+// resolver1.opendns.com.
+func NewOpenDNS() *DNSDiscoverer {
+	return &DNSDiscoverer{
+		Label:     "dns:opendns",
+		ServerV4:  "208.67.222.222:53",
+		ServerV6:  "[2620:119:35::35]:53",
+		QueryName: "myip.opendns.com",
+	}
+}
+
+// Name implements IPDiscoverer.
+func (d *DNSDiscoverer) Name() string { return d.Label }
+
+// DiscoverIP implements IPDiscoverer. For IPv6, errors caused by the host
+// lacking an IPv6 route wrap ErrNoIPv6.
+func (d *DNSDiscoverer) DiscoverIP(ctx context.Context, family Family) (string, error) {
+	server := d.ServerV4
+	if family == IPv6 {
+		server = d.ServerV6
+	}
+	if server == "" || (family != IPv4 && family != IPv6) {
+		return "", fmt.Errorf("%s: %w: %s", d.Label, ErrUnsupportedFamily, family)
+	}
+	qtype, qclass := uint16(dnsTypeA), uint16(dnsClassIN)
+	switch {
+	case d.TXT:
+		qtype, qclass = dnsTypeTXT, dnsClassCH
+	case family == IPv6:
+		qtype = dnsTypeAAAA
+	}
+	id := uint16(rand.N(1 << 16))
+	query, err := buildDNSQuery(id, d.QueryName, qtype, qclass)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := d.exchange(ctx, server, query)
+	if err == nil {
+		var answers [][]byte
+		answers, err = parseDNSAnswers(resp, id, qtype)
+		if errors.Is(err, errDNSTruncated) {
+			if resp, err = d.exchangeTCP(ctx, server, query); err == nil {
+				answers, err = parseDNSAnswers(resp, id, qtype)
+			}
+		}
+		if err == nil {
+			return d.answerIP(answers[0], family)
+		}
+	}
+	if family == IPv6 && isNoRoute(err) {
+		return "", fmt.Errorf("%w: %w", ErrNoIPv6, err)
+	}
+	return "", err
+}
+
+// answerIP converts answer rdata into an address of the requested family.
+func (d *DNSDiscoverer) answerIP(rdata []byte, family Family) (string, error) {
+	if d.TXT {
+		text, err := decodeTXT(rdata)
+		if err != nil {
+			return "", err
+		}
+		return parseIP(text, family == IPv6)
+	}
+	if len(rdata) != net.IPv4len && len(rdata) != net.IPv6len {
+		return "", fmt.Errorf("dns: unexpected address length %d", len(rdata))
+	}
+	return parseIP(net.IP(rdata).String(), family == IPv6)
+}
+
+// exchange sends query over UDP, retransmitting on timeout, and returns the
+// first response carrying the query's ID.
+func (d *DNSDiscoverer) exchange(ctx context.Context, server string, query []byte) ([]byte, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	timeout := d.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	attempts := d.Attempts
+	if attempts <= 0 {
+		attempts = 2
+	}
+	resp, err := udpExchange(ctx, conn, query, fixedWaits(attempts, timeout), func(resp []byte) ([]byte, error) {
+		if len(resp) < 2 || binary.BigEndian.Uint16(resp) != binary.BigEndian.Uint16(query) {
+			return nil, errUnrelated
+		}
+		return append([]byte(nil), resp...), nil
+	})
+	if err != nil && ctx.Err() == nil {
+		return nil, fmt.Errorf("dns %s: %w", server, err)
+	}
+	return resp, err
+}
+
+// exchangeTCP sends query over TCP with the RFC 1035 length prefix.
+func (d *DNSDiscoverer) exchangeTCP(ctx context.Context, server string, query []byte) ([]byte, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	msg := binary.BigEndian.AppendUint16(make([]byte, 0, 2+len(query)), uint16(len(query)))
+	if _, err := conn.Write(append(msg, query...)); err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	var size [2]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(size[:]))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,197 @@
+package netutil_test
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jsirianni/cloudflare-go/internal/netutil"
+	"github.com/stretchr/testify/require"
+)
+
+// dnsStandIn is a local resolver answering every question with fixed rdata.
+type dnsStandIn struct {
+	udp      net.PacketConn
+	tcp      net.Listener
+	answers  map[uint16][]byte // rdata by query type
+	truncate bool              // set TC on UDP answers
+	drop     atomic.Int32      // UDP queries to ignore before answering
+	badID    bool              // send a response with the wrong ID first
+	queries  atomic.Int32
+}
+
+func newDNSStandIn(t *testing.T, answers map[uint16][]byte, configure ...func(*dnsStandIn)) *dnsStandIn {
+	t.Helper()
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		udp.Close()
+		t.Skipf("cannot bind TCP on the UDP port: %v", err)
+	}
+	s := &dnsStandIn{udp: udp, tcp: tcp, answers: answers}
+	for _, f := range configure {
+		f(s)
+	}
+	t.Cleanup(func() { udp.Close(); tcp.Close() })
+	go s.serveUDP()
+	go s.serveTCP()
+	return s
+}
+
+func (s *dnsStandIn) addr() string { return s.udp.LocalAddr().String() }
+
+func (s *dnsStandIn) serveUDP() {
+	buf := make([]byte, 512)
+	for {
+		n, from, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		s.queries.Add(1)
+		if s.drop.Add(-1) >= 0 {
+			continue
+		}
+		resp := s.respond(buf[:n], s.truncate)
+		if s.badID {
+			bogus := append([]byte(nil), resp...)
+			bogus[0] ^= 0xFF
+			s.udp.WriteTo(bogus, from)
+		}
+		s.udp.WriteTo(resp, from)
+	}
+}
+
+func (s *dnsStandIn) serveTCP() {
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+		var size [2]byte
+		if _, err := io.ReadFull(conn, size[:]); err == nil {
+			q := make([]byte, binary.BigEndian.Uint16(size[:]))
+			if _, err := io.ReadFull(conn, q); err == nil {
+				resp := s.respond(q, false)
+				conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(resp))), resp...))
+			}
+		}
+		conn.Close()
+	}
+}
+
+// respond echoes the question and appends one answer pointing at it.
+func (s *dnsStandIn) respond(q []byte, truncate bool) []byte {
+	end := 12
+	for q[end] != 0 {
+		end += int(q[end]) + 1
+	}
+	end += 5
+	qtype := binary.BigEndian.Uint16(q[end-4:])
+	qclass := binary.BigEndian.Uint16(q[end-2:])
+
+	resp := append([]byte(nil), q[:end]...)
+	flags := uint16(0x8180)
+	if truncate {
+		flags |= 0x0200
+	}
+	binary.BigEndian.PutUint16(resp[2:], flags)
+	rdata, ok := s.answers[qtype]
+	if !ok || truncate {
+		return resp
+	}
+	binary.BigEndian.PutUint16(resp[6:], 1)
+	resp = append(resp, 0xC0, 12)
+	resp = binary.BigEndian.AppendUint16(resp, qtype)
+	resp = binary.BigEndian.AppendUint16(resp, qclass)
+	resp = binary.BigEndian.AppendUint32(resp, 0)
+	resp = binary.BigEndian.AppendUint16(resp, uint16(len(rdata)))
+	return append(resp, rdata...)
+}
+
+func txtRData(s string) []byte { return append([]byte{byte(len(s))}, s...) }
+
+func TestDNSDiscoverer_WhoamiTXT(t *testing.T) {
+	srv := newDNSStandIn(t, map[uint16][]byte{16: txtRData("203.0.113.9")})
+	d := netutil.NewCloudflareDNS()
+	d.ServerV4, d.ServerV6 = srv.addr(), srv.addr()
+
+	ip, err := d.DiscoverIP(context.Background(), netutil.IPv4)
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.9", ip)
+
+	_, err = d.DiscoverIP(context.Background(), netutil.IPv6)
+	require.Error(t, err, "IPv4 answer must not satisfy an IPv6 lookup")
+}
+
+func TestDNSDiscoverer_AddressRecords(t *testing.T) {
+	srv := newDNSStandIn(t, map[uint16][]byte{
+		1:  net.ParseIP("198.51.100.4").To4(),
+		28: net.ParseIP("2001:db8::4"),
+	})
+	d := netutil.NewOpenDNS()
+	d.ServerV4, d.ServerV6 = srv.addr(), srv.addr()
+
+	ip, err := d.DiscoverIP(context.Background(), netutil.IPv4)
+	require.NoError(t, err)
+	require.Equal(t, "198.51.100.4", ip)
+
+	ip, err = d.DiscoverIP(context.Background(), netutil.IPv6)
+	require.NoError(t, err)
+	require.Equal(t, "2001:db8::4", ip)
+}
+
+func TestDNSDiscoverer_TruncatedFallsBackToTCP(t *testing.T) {
+	srv := newDNSStandIn(t, map[uint16][]byte{16: txtRData("203.0.113.10")}, func(s *dnsStandIn) { s.truncate = true })
+	d := netutil.NewCloudflareDNS()
+	d.ServerV4 = srv.addr()
+
+	ip, err := d.DiscoverIP(context.Background(), netutil.IPv4)
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.10", ip)
+}
+
+func TestDNSDiscoverer_RetransmitsAndIgnoresForeignIDs(t *testing.T) {
+	srv := newDNSStandIn(t, map[uint16][]byte{16: txtRData("203.0.113.11")}, func(s *dnsStandIn) {
+		s.drop.Store(1)
+		s.badID = true
+	})
+	d := netutil.NewCloudflareDNS()
+	d.ServerV4 = srv.addr()
+	d.Timeout = 100 * time.Millisecond
+
+	ip, err := d.DiscoverIP(context.Background(), netutil.IPv4)
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.11", ip)
+	require.Equal(t, int32(2), srv.queries.Load())
+}
+
+func TestDNSDiscoverer_NoResponse(t *testing.T) {
+	srv := newDNSStandIn(t, nil, func(s *dnsStandIn) { s.drop.Store(100) })
+	d := netutil.NewCloudflareDNS()
+	d.ServerV4 = srv.addr()
+	d.Timeout = 20 * time.Millisecond
+	d.Attempts = 3
+
+	_, err := d.DiscoverIP(context.Background(), netutil.IPv4)
+	require.ErrorContains(t, err, "no response after 3 transmissions")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	d.Timeout = time.Minute
+	_, err = d.DiscoverIP(ctx, netutil.IPv4)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestDNSDiscoverer_NoAnswer(t *testing.T) {
+	srv := newDNSStandIn(t, nil)
+	d := netutil.NewCloudflareDNS()
+	d.ServerV4 = srv.addr()
+
+	_, err := d.DiscoverIP(context.Background(), netutil.IPv4)
+	require.ErrorContains(t, err, "no answer")
+}
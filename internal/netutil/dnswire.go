@@ -0,0 +1,140 @@
+package netutil
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DNS constants from RFC 1035 used by the minimal resolver client.
+const (
+	dnsTypeA    = 1
+	dnsTypeTXT  = 16
+	dnsTypeAAAA = 28
+
+	dnsClassIN = 1
+	dnsClassCH = 3
+
+	dnsHeaderLen   = 12
+	dnsFlagQR      = 1 << 15
+	dnsFlagTC      = 1 << 9
+	dnsFlagRD      = 1 << 8
+	dnsRcodeMask   = 0xF
+	dnsMaxLabelLen = 63
+	dnsMaxNameLen  = 255
+	dnsPointerMask = 0xC0
+)
+
+// errDNSTruncated reports a UDP response with the TC bit set.
+var errDNSTruncated = errors.New("dns: response truncated")
+
+// buildDNSQuery encodes a single-question query with recursion desired.
+func buildDNSQuery(id uint16, name string, qtype, qclass uint16) ([]byte, error) {
+	msg := make([]byte, dnsHeaderLen, dnsHeaderLen+len(name)+6)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[2:], dnsFlagRD)
+	binary.BigEndian.PutUint16(msg[4:], 1) // QDCOUNT
+	name = strings.TrimSuffix(name, ".")
+	if len(name)+2 > dnsMaxNameLen {
+		return nil, fmt.Errorf("dns: name %q too long", name)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > dnsMaxLabelLen {
+			return nil, fmt.Errorf("dns: invalid name %q", name)
+		}
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+	msg = binary.BigEndian.AppendUint16(msg, qclass)
+	return msg, nil
+}
+
+// parseDNSAnswers returns the rdata of every answer of type qtype in the
+// response to query id. It returns errDNSTruncated if the TC bit is set.
+func parseDNSAnswers(msg []byte, id, qtype uint16) ([][]byte, error) {
+	if len(msg) < dnsHeaderLen {
+		return nil, errors.New("dns: short response")
+	}
+	if binary.BigEndian.Uint16(msg[0:]) != id {
+		return nil, errors.New("dns: response id mismatch")
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if flags&dnsFlagQR == 0 {
+		return nil, errors.New("dns: not a response")
+	}
+	if flags&dnsFlagTC != 0 {
+		return nil, errDNSTruncated
+	}
+	if rcode := flags & dnsRcodeMask; rcode != 0 {
+		return nil, fmt.Errorf("dns: server returned rcode %d", rcode)
+	}
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	ancount := int(binary.BigEndian.Uint16(msg[6:]))
+
+	off := dnsHeaderLen
+	for range qdcount {
+		var err error
+		if off, err = skipDNSName(msg, off); err != nil {
+			return nil, err
+		}
+		off += 4 // QTYPE, QCLASS
+	}
+	var out [][]byte
+	for range ancount {
+		var err error
+		if off, err = skipDNSName(msg, off); err != nil {
+			return nil, err
+		}
+		if off+10 > len(msg) {
+			return nil, errors.New("dns: truncated answer")
+		}
+		typ := binary.BigEndian.Uint16(msg[off:])
+		rdlen := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10
+		if off+rdlen > len(msg) {
+			return nil, errors.New("dns: truncated rdata")
+		}
+		if typ == qtype {
+			out = append(out, msg[off:off+rdlen])
+		}
+		off += rdlen
+	}
+	if len(out) == 0 {
+		return nil, errors.New("dns: no answer")
+	}
+	return out, nil
+}
+
+// skipDNSName returns the offset just past the (possibly compressed) name at off.
+func skipDNSName(msg []byte, off int) (int, error) {
+	for {
+		if off >= len(msg) {
+			return 0, errors.New("dns: truncated name")
+		}
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			return off + 1, nil
+		case n&dnsPointerMask == dnsPointerMask:
+			return off + 2, nil
+		}
+		off += 1 + n
+	}
+}
+
+// decodeTXT concatenates the character-strings of TXT rdata.
+func decodeTXT(rdata []byte) (string, error) {
+	var b strings.Builder
+	for len(rdata) > 0 {
+		n := int(rdata[0])
+		if 1+n > len(rdata) {
+			return "", errors.New("dns: malformed TXT rdata")
+		}
+		b.Write(rdata[1 : 1+n])
+		rdata = rdata[1+n:]
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,55 @@
+//go:build linux
+
+package netutil
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// rtfGateway is the RTF_GATEWAY route flag from linux/route.h.
+const rtfGateway = 0x2
+
+// DefaultGateway returns the IPv4 default gateway from /proc/net/route.
+func DefaultGateway() (net.IP, error) {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseProcRoute(f)
+}
+
+// parseProcRoute finds the default route in the /proc/net/route format, whose
+// addresses are little-endian hex.
+func parseProcRoute(r io.Reader) (net.IP, error) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 4 || fields[1] != "00000000" {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 16)
+		if err != nil || flags&rtfGateway == 0 {
+			continue
+		}
+		raw, err := hex.DecodeString(fields[2])
+		if err != nil || len(raw) != net.IPv4len {
+			continue
+		}
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(raw))
+		return ip, nil
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("no IPv4 default gateway")
+}
@@ -0,0 +1,23 @@
+//go:build linux
+
+package netutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProcRoute(t *testing.T) {
+	const sample = `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	0000A8C0	00000000	0001	0	0	0	00FFFFFF	0	0	0
+eth0	00000000	0100A8C0	0003	0	0	100	00000000	0	0	0
+`
+	gw, err := parseProcRoute(strings.NewReader(sample))
+	require.NoError(t, err)
+	require.Equal(t, "192.168.0.1", gw.String())
+
+	_, err = parseProcRoute(strings.NewReader("Iface\tDestination\tGateway\tFlags\n"))
+	require.Error(t, err)
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package netutil
+
+import (
+	"errors"
+	"net"
+)
+
+// DefaultGateway is not implemented on this platform; configure the gateway
+// address explicitly.
+func DefaultGateway() (net.IP, error) {
+	return nil, errors.New("default gateway lookup is only supported on Linux; set the gateway explicitly")
+}
@@ -0,0 +1,178 @@
+package netutil_test
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jsirianni/cloudflare-go/internal/netutil"
+	"github.com/stretchr/testify/require"
+)
+
+// udpResponder answers each datagram with reply(request); nil replies are dropped.
+func udpResponder(t *testing.T, reply func(req []byte) []byte) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := reply(append([]byte(nil), buf[:n]...)); resp != nil {
+				conn.WriteTo(resp, from)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func natpmpReply(code uint16, ip string) func([]byte) []byte {
+	return func(req []byte) []byte {
+		if len(req) != 2 || req[0] != 0 || req[1] != 0 {
+			return nil
+		}
+		resp := []byte{0, 128}
+		resp = binary.BigEndian.AppendUint16(resp, code)
+		resp = binary.BigEndian.AppendUint32(resp, 42) // epoch
+		return append(resp, net.ParseIP(ip).To4()...)
+	}
+}
+
+func TestNATPMP(t *testing.T) {
+	ctx := context.Background()
+
+	ip, err := netutil.NewNATPMP(udpResponder(t, natpmpReply(0, "203.0.113.60"))).DiscoverIP(ctx, netutil.IPv4)
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.60", ip)
+
+	_, err = netutil.NewNATPMP(udpResponder(t, natpmpReply(3, "0.0.0.0"))).DiscoverIP(ctx, netutil.IPv4)
+	require.ErrorContains(t, err, "result code 3")
+
+	_, err = netutil.NewNATPMP(udpResponder(t, natpmpReply(0, "100.64.1.2"))).DiscoverIP(ctx, netutil.IPv4)
+	require.ErrorContains(t, err, "double NAT")
+
+	_, err = netutil.NewNATPMP("127.0.0.1").DiscoverIP(ctx, netutil.IPv6)
+	require.ErrorIs(t, err, netutil.ErrUnsupportedFamily)
+}
+
+func TestPCP(t *testing.T) {
+	lifetimes := make(chan uint32, 4)
+	addr := udpResponder(t, func(req []byte) []byte {
+		if len(req) != 60 || req[0] != 2 || req[1] != 1 {
+			return nil
+		}
+		lifetime := binary.BigEndian.Uint32(req[4:])
+		lifetimes <- lifetime
+		if lifetime == 0 {
+			return nil
+		}
+		resp := make([]byte, 60)
+		resp[0], resp[1] = 2, 0x81
+		binary.BigEndian.PutUint32(resp[4:], lifetime)
+		copy(resp[24:36], req[24:36]) // nonce
+		copy(resp[36:44], req[36:44]) // protocol, internal port
+		binary.BigEndian.PutUint16(resp[42:], 61000)
+		copy(resp[44:60], net.ParseIP("198.51.100.70").To16())
+		return resp
+	})
+	ip, err := netutil.NewPCP(addr).DiscoverIP(context.Background(), netutil.IPv4)
+	require.NoError(t, err)
+	require.Equal(t, "198.51.100.70", ip)
+	require.Equal(t, uint32(120), <-lifetimes)
+	require.Equal(t, uint32(0), <-lifetimes, "probe mapping must be deleted")
+}
+
+const igdDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+    <deviceList><device>
+      <deviceType>urn:schemas-upnp-org:device:WANDevice:1</deviceType>
+      <deviceList><device>
+        <deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:1</deviceType>
+        <serviceList><service>
+          <serviceType>urn:schemas-upnp-org:service:WANIPConnection:1</serviceType>
+          <controlURL>/ctl/IPConn</controlURL>
+        </service></serviceList>
+      </device></deviceList>
+    </device></deviceList>
+  </device>
+</root>`
+
+func newIGD(t *testing.T, externalIP string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rootDesc.xml":
+			io.WriteString(w, igdDescription)
+		case "/ctl/IPConn":
+			require.Equal(t, http.MethodPost, r.Method)
+			require.Equal(t, `"urn:schemas-upnp-org:service:WANIPConnection:1#GetExternalIPAddress"`, r.Header.Get("SOAPAction"))
+			b, _ := io.ReadAll(r.Body)
+			require.Contains(t, string(b), "GetExternalIPAddress")
+			fmt.Fprintf(w, `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>`+
+				`<u:GetExternalIPAddressResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1">`+
+				`<NewExternalIPAddress>%s</NewExternalIPAddress></u:GetExternalIPAddressResponse></s:Body></s:Envelope>`, externalIP)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestUPnP_Location(t *testing.T) {
+	igd := newIGD(t, "203.0.113.80")
+	ip, err := netutil.NewUPnP(igd.URL+"/rootDesc.xml").DiscoverIP(context.Background(), netutil.IPv4)
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.80", ip)
+
+	_, err = netutil.NewUPnP(igd.URL+"/missing.xml").DiscoverIP(context.Background(), netutil.IPv4)
+	require.ErrorContains(t, err, "404")
+}
+
+func TestUPnP_SSDP(t *testing.T) {
+	igd := newIGD(t, "203.0.113.81")
+	ssdp := udpResponder(t, func(req []byte) []byte {
+		if !strings.HasPrefix(string(req), "M-SEARCH * HTTP/1.1\r\n") || !strings.Contains(string(req), "ST: urn:schemas-upnp-org:device:InternetGatewayDevice:1") {
+			return nil
+		}
+		return []byte("HTTP/1.1 200 OK\r\nCACHE-CONTROL: max-age=120\r\nST: urn:schemas-upnp-org:device:InternetGatewayDevice:1\r\n" +
+			"LOCATION: " + igd.URL + "/rootDesc.xml\r\n\r\n")
+	})
+	d := &netutil.UPnPDiscoverer{SSDPAddr: ssdp, SearchWait: time.Second}
+	ip, err := d.DiscoverIP(context.Background(), netutil.IPv4)
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.81", ip)
+
+	silent := udpResponder(t, func([]byte) []byte { return nil })
+	d = &netutil.UPnPDiscoverer{SSDPAddr: silent, SearchWait: 20 * time.Millisecond}
+	_, err = d.DiscoverIP(context.Background(), netutil.IPv4)
+	require.ErrorContains(t, err, "no internet gateway device")
+}
+
+func TestInterfaceDiscoverer(t *testing.T) {
+	d := netutil.NewInterfaceDiscoverer("does-not-exist0")
+	require.Equal(t, "interface:does-not-exist0", d.Name())
+	_, err := d.DiscoverIP(context.Background(), netutil.IPv4)
+	require.Error(t, err)
+	_, err = d.DiscoverIP(context.Background(), netutil.IPv6)
+	require.ErrorIs(t, err, netutil.ErrNoIPv6)
+
+	lo, err := net.InterfaceByIndex(1)
+	if err == nil && lo.Flags&net.FlagLoopback != 0 {
+		_, err = netutil.DiscoverIPv4ViaInterface(context.Background(), lo.Name)
+		require.ErrorContains(t, err, "no public IPv4 address", "loopback is not public")
+	}
+}
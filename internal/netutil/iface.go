@@ -0,0 +1,169 @@
+package netutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// cgnatRange is the RFC 6598 shared address space used by carrier-grade NAT.
+var cgnatRange = &net.IPNet{IP: net.IPv4(100, 64, 0, 0).To4(), Mask: net.CIDRMask(10, 32)}
+
+// InterfaceDiscoverer reads the public address assigned directly to a local
+// interface, as on a router or a host with a public address. It needs no
+// network traffic.
+type InterfaceDiscoverer struct {
+	// Interface restricts the search to one interface; empty scans all.
+	Interface string
+}
+
+// NewInterfaceDiscoverer returns a discoverer for the named interface (empty
+// for any interface).
+func NewInterfaceDiscoverer(iface string) *InterfaceDiscoverer {
+	return &InterfaceDiscoverer{Interface: iface}
+}
+
+// Name implements IPDiscoverer.
+func (d *InterfaceDiscoverer) Name() string {
+	if d.Interface == "" {
+		return "interface"
+	}
+	return "interface:" + d.Interface
+}
+
+// DiscoverIP implements IPDiscoverer.
+func (d *InterfaceDiscoverer) DiscoverIP(ctx context.Context, family Family) (string, error) {
+	switch family {
+	case IPv4:
+		return DiscoverIPv4ViaInterface(ctx, d.Interface)
+	case IPv6:
+		return DiscoverIPv6ViaInterface(ctx, d.Interface)
+	}
+	return "", fmt.Errorf("%s: %w: %s", d.Name(), ErrUnsupportedFamily, family)
+}
+
+// DiscoverIPv4ViaInterface returns the first public IPv4 address assigned to
+// a local interface. iface restricts the search to one interface; empty scans
+// all of them. Private, shared (CGNAT), loopback and link-local addresses are
+// skipped.
+func DiscoverIPv4ViaInterface(ctx context.Context, iface string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	var ifaces []net.Interface
+	if iface != "" {
+		ifc, err := net.InterfaceByName(iface)
+		if err != nil {
+			return "", err
+		}
+		ifaces = []net.Interface{*ifc}
+	} else {
+		all, err := net.Interfaces()
+		if err != nil {
+			return "", err
+		}
+		ifaces = all
+	}
+	for _, ifc := range ifaces {
+		if ifc.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := ifc.Addrs()
+		if err != nil {
+			return "", err
+		}
+		for _, a := range addrs {
+			if ipn, ok := a.(*net.IPNet); ok && isPublicIPv4(ipn.IP) {
+				return ipn.IP.To4().String(), nil
+			}
+		}
+	}
+	if iface != "" {
+		return "", fmt.Errorf("no public IPv4 address on interface %s", iface)
+	}
+	return "", errors.New("no public IPv4 address on any interface")
+}
+
+// isPublicIPv4 reports whether ip is a globally routable IPv4 address.
+func isPublicIPv4(ip net.IP) bool {
+	ip4 := ip.To4()
+	return ip4 != nil && ip4.IsGlobalUnicast() && !ip4.IsPrivate() && !cgnatRange.Contains(ip4)
+}
+
+// InterfaceAddr is a local IPv6 address together with the kernel flags that
+// matter when choosing an address to publish.
+type InterfaceAddr struct {
+	Interface string
+	IP        net.IP
+	// Temporary marks RFC 4941 privacy addresses, which rotate and should not
+	// be published in DNS.
+	Temporary bool
+	// Deprecated marks addresses past their preferred lifetime.
+	Deprecated bool
+	// Tentative marks addresses still undergoing duplicate address detection.
+	Tentative bool
+}
+
+// DiscoverIPv6ViaInterface returns a stable global IPv6 address assigned to a
+// local interface. iface restricts the search to one interface; empty scans
+// all of them. It returns an error wrapping ErrNoIPv6 if no address qualifies.
+func DiscoverIPv6ViaInterface(ctx context.Context, iface string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	addrs, err := LocalIPv6Addrs()
+	if err != nil {
+		return "", err
+	}
+	return PickGlobalIPv6(addrs, iface)
+}
+
+// PickGlobalIPv6 selects the first global-scope, non-temporary, non-deprecated
+// and non-tentative address from addrs, optionally restricted to iface.
+// Unique local (fc00::/7) and IPv4-mapped addresses are never selected.
+func PickGlobalIPv6(addrs []InterfaceAddr, iface string) (string, error) {
+	for _, a := range addrs {
+		if iface != "" && a.Interface != iface {
+			continue
+		}
+		if a.Temporary || a.Deprecated || a.Tentative {
+			continue
+		}
+		if a.IP.To4() != nil || !a.IP.IsGlobalUnicast() || a.IP.IsPrivate() {
+			continue
+		}
+		return a.IP.String(), nil
+	}
+	if iface != "" {
+		return "", fmt.Errorf("%w: no global address on interface %s", ErrNoIPv6, iface)
+	}
+	return "", fmt.Errorf("%w: no global address on any interface", ErrNoIPv6)
+}
+
+// interfaceIPv6Addrs lists IPv6 addresses using the portable net package API,
+// which does not expose address flags.
+func interfaceIPv6Addrs() ([]InterfaceAddr, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var out []InterfaceAddr
+	for _, ifc := range ifaces {
+		if ifc.Flags&net.FlagUp == 0 || ifc.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := ifc.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range addrs {
+			ipn, ok := a.(*net.IPNet)
+			if !ok || ipn.IP.To4() != nil {
+				continue
+			}
+			out = append(out, InterfaceAddr{Interface: ifc.Name, IP: ipn.IP})
+		}
+	}
+	return out, nil
+}
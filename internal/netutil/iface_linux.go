@@ -0,0 +1,67 @@
+//go:build linux
+
+package netutil
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Address flags from linux/if_addr.h as reported in /proc/net/if_inet6.
+const (
+	ifaFlagTemporary  = 0x01
+	ifaFlagDADFailed  = 0x08
+	ifaFlagDeprecated = 0x20
+	ifaFlagTentative  = 0x40
+)
+
+// LocalIPv6Addrs lists the host's IPv6 addresses with their kernel flags, read
+// from /proc/net/if_inet6. It falls back to the net package, without flags,
+// if procfs is unavailable.
+func LocalIPv6Addrs() ([]InterfaceAddr, error) {
+	f, err := os.Open("/proc/net/if_inet6")
+	if errors.Is(err, os.ErrNotExist) {
+		return interfaceIPv6Addrs()
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseIfInet6(f)
+}
+
+// parseIfInet6 parses the /proc/net/if_inet6 format:
+// address, ifindex, prefix length, scope, flags and interface name.
+func parseIfInet6(r io.Reader) ([]InterfaceAddr, error) {
+	var out []InterfaceAddr
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 6 {
+			continue
+		}
+		raw, err := hex.DecodeString(fields[0])
+		if err != nil || len(raw) != net.IPv6len {
+			return nil, fmt.Errorf("if_inet6: invalid address %q", fields[0])
+		}
+		flags, err := strconv.ParseUint(fields[4], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("if_inet6: invalid flags %q", fields[4])
+		}
+		out = append(out, InterfaceAddr{
+			Interface:  fields[5],
+			IP:         net.IP(raw),
+			Temporary:  flags&ifaFlagTemporary != 0,
+			Deprecated: flags&ifaFlagDeprecated != 0,
+			Tentative:  flags&(ifaFlagTentative|ifaFlagDADFailed) != 0,
+		})
+	}
+	return out, sc.Err()
+}
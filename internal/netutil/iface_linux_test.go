@@ -0,0 +1,33 @@
+//go:build linux
+
+package netutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIfInet6(t *testing.T) {
+	const sample = `00000000000000000000000000000001 01 80 10 80       lo
+fe8000000000000000fc00fffe000001 04 40 20 80     eth0
+20010db8000000000000000000000010 04 40 00 00     eth0
+20010db800000000a1b2c3d4e5f60718 04 40 00 01     eth0
+20010db8000000000000000000000099 04 40 00 20     eth0
+`
+	addrs, err := parseIfInet6(strings.NewReader(sample))
+	require.NoError(t, err)
+	require.Len(t, addrs, 5)
+	require.Equal(t, "eth0", addrs[2].Interface)
+	require.Equal(t, "2001:db8::10", addrs[2].IP.String())
+	require.True(t, addrs[3].Temporary)
+	require.True(t, addrs[4].Deprecated)
+
+	ip, err := PickGlobalIPv6(addrs, "")
+	require.NoError(t, err)
+	require.Equal(t, "2001:db8::10", ip)
+
+	_, err = parseIfInet6(strings.NewReader("zz 01 80 10 80 lo\n"))
+	require.Error(t, err)
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package netutil
+
+// LocalIPv6Addrs lists the host's IPv6 addresses. Address flags are not
+// available on this platform, so temporary addresses cannot be excluded.
+func LocalIPv6Addrs() ([]InterfaceAddr, error) {
+	return interfaceIPv6Addrs()
+}
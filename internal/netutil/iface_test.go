@@ -0,0 +1,53 @@
+package netutil_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/jsirianni/cloudflare-go/internal/netutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickGlobalIPv6(t *testing.T) {
+	addr := func(iface, ip string) netutil.InterfaceAddr {
+		return netutil.InterfaceAddr{Interface: iface, IP: net.ParseIP(ip)}
+	}
+	temp := addr("eth0", "2001:db8::aaaa")
+	temp.Temporary = true
+	depr := addr("eth0", "2001:db8::bbbb")
+	depr.Deprecated = true
+	tent := addr("eth0", "2001:db8::cccc")
+	tent.Tentative = true
+
+	addrs := []netutil.InterfaceAddr{
+		addr("lo", "::1"),
+		addr("eth0", "fe80::1"),
+		addr("eth0", "fd00::2"),
+		addr("eth0", "::ffff:203.0.113.1"),
+		temp, depr, tent,
+		addr("eth0", "2001:db8::10"),
+		addr("wlan0", "2001:db8:1::20"),
+	}
+
+	ip, err := netutil.PickGlobalIPv6(addrs, "")
+	require.NoError(t, err)
+	require.Equal(t, "2001:db8::10", ip)
+
+	ip, err = netutil.PickGlobalIPv6(addrs, "wlan0")
+	require.NoError(t, err)
+	require.Equal(t, "2001:db8:1::20", ip)
+
+	_, err = netutil.PickGlobalIPv6(addrs, "eth1")
+	require.ErrorIs(t, err, netutil.ErrNoIPv6)
+
+	_, err = netutil.PickGlobalIPv6(addrs[:7], "")
+	require.ErrorIs(t, err, netutil.ErrNoIPv6)
+}
+
+func TestDiscoverIPv6ViaInterface_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := netutil.DiscoverIPv6ViaInterface(ctx, "")
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -4,38 +4,52 @@ package netutil
 import (
 	"context"
 	"errors"
-	"io"
 	"net"
 	"net/http"
+	"syscall"
 	"time"
 )
 
+// ErrNoIPv6 indicates the host has no usable IPv6 address or route, as opposed
+// to a transient discovery failure.
+var ErrNoIPv6 = errors.New("no IPv6 connectivity")
+
+// maxEchoBody bounds the response read from an IP echo service.
+const maxEchoBody = 1 << 10
+
 // DiscoverIPv4ViaIpify fetches the public IPv4 address using ipify.org.
 func DiscoverIPv4ViaIpify(ctx context.Context, client *http.Client) (string, error) {
-	const ipifyURL = "https://api.ipify.org"
 	if client == nil {
 		client = &http.Client{Timeout: 10 * time.Second}
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ipifyURL, nil)
-	if err != nil {
-		return "", err
-	}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return "", errors.New(resp.Status)
-	}
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+	return NewIpify(client).DiscoverIP(ctx, IPv4)
+}
+
+// DiscoverIPv6ViaIpify fetches the public IPv6 address using api6.ipify.org,
+// which is only reachable over IPv6. Errors caused by the host lacking an IPv6
+// route wrap ErrNoIPv6.
+func DiscoverIPv6ViaIpify(ctx context.Context, client *http.Client) (string, error) {
+	return NewIpify(client).DiscoverIP(ctx, IPv6)
+}
+
+// parseIP validates s as an address of the requested family.
+func parseIP(s string, v6 bool) (string, error) {
+	parsed := net.ParseIP(s)
+	if v6 {
+		if parsed == nil || parsed.To4() != nil {
+			return "", errors.New("invalid IPv6 response")
+		}
+		return parsed.String(), nil
 	}
-	ip := string(b)
-	parsed := net.ParseIP(ip)
 	if parsed == nil || parsed.To4() == nil {
 		return "", errors.New("invalid IPv4 response")
 	}
-	return parsed.String(), nil
+	return parsed.To4().String(), nil
+}
+
+// isNoRoute reports whether err means the destination is unreachable from
+// this host, e.g. dialing an IPv6-only service without an IPv6 route.
+func isNoRoute(err error) bool {
+	return errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, syscall.EHOSTUNREACH) ||
+		errors.Is(err, syscall.EADDRNOTAVAIL)
 }
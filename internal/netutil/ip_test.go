@@ -5,8 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"os"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
@@ -146,3 +149,34 @@ func TestDiscoverIPv4ViaIpify_TableDriven(t *testing.T) {
 		})
 	}
 }
+
+func TestDiscoverIPv6ViaIpify(t *testing.T) {
+	cases := []struct {
+		name      string
+		transport stubTransport
+		wantIP    string
+		wantErr   string
+		noIPv6    bool
+	}{
+		{name: "success", transport: stubTransport{body: "2001:db8::1\n"}, wantIP: "2001:db8::1"},
+		{name: "ipv4 not accepted", transport: stubTransport{body: "203.0.113.42"}, wantErr: "invalid IPv6 response"},
+		{name: "non-2xx status", transport: stubTransport{status: http.StatusBadGateway}, wantErr: "502"},
+		{name: "no route", transport: stubTransport{err: &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ENETUNREACH)}}, noIPv6: true},
+		{name: "other network error", transport: stubTransport{err: errors.New("tls handshake")}, wantErr: "tls handshake"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ip, err := netutil.DiscoverIPv6ViaIpify(context.Background(), &http.Client{Transport: tc.transport})
+			switch {
+			case tc.noIPv6:
+				require.ErrorIs(t, err, netutil.ErrNoIPv6)
+			case tc.wantErr != "":
+				require.ErrorContains(t, err, tc.wantErr)
+				require.NotErrorIs(t, err, netutil.ErrNoIPv6)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.wantIP, ip)
+			}
+		})
+	}
+}
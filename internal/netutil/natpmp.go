@@ -0,0 +1,197 @@
+package netutil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// NAT-PMP (RFC 6886) and PCP (RFC 6887) protocol constants.
+const (
+	natpmpPort            = 5351
+	natpmpVersion         = 0
+	natpmpOpExternalAddr  = 0
+	natpmpResponseBit     = 0x80
+	natpmpExternalRespLen = 12
+	natpmpDefaultRTO      = 250 * time.Millisecond
+	natpmpDefaultTries    = 4
+
+	pcpVersion     = 2
+	pcpOpMap       = 1
+	pcpResponseBit = 0x80
+	pcpHeaderLen   = 24
+	pcpMapLen      = 36
+	pcpProtoUDP    = 17
+	// pcpProbePort is the internal port of the throwaway mapping used to learn
+	// the external address (the discard service, which nothing listens on).
+	pcpProbePort     = 9
+	pcpProbeLifetime = 120
+)
+
+// NATPMPDiscoverer asks the gateway for its external IPv4 address with the
+// NAT-PMP "external address" request. It only talks to the local gateway.
+type NATPMPDiscoverer struct {
+	// Gateway is the router address (IP or IP:port); empty uses the default
+	// gateway.
+	Gateway string
+	// RTO is the initial retransmission timeout, doubled after each attempt;
+	// zero means 250ms.
+	RTO time.Duration
+	// Transmissions is the number of requests sent; zero means 4.
+	Transmissions int
+}
+
+// NewNATPMP returns a NAT-PMP discoverer for gateway (empty for the default
+// gateway).
+func NewNATPMP(gateway string) *NATPMPDiscoverer {
+	return &NATPMPDiscoverer{Gateway: gateway}
+}
+
+// Name implements IPDiscoverer.
+func (d *NATPMPDiscoverer) Name() string { return "natpmp" }
+
+// DiscoverIP implements IPDiscoverer. Only IPv4 is supported.
+func (d *NATPMPDiscoverer) DiscoverIP(ctx context.Context, family Family) (string, error) {
+	if family != IPv4 {
+		return "", fmt.Errorf("natpmp: %w: %s", ErrUnsupportedFamily, family)
+	}
+	conn, err := dialGateway(ctx, d.Gateway)
+	if err != nil {
+		return "", fmt.Errorf("natpmp: %w", err)
+	}
+	defer conn.Close()
+
+	req := []byte{natpmpVersion, natpmpOpExternalAddr}
+	waits := gatewayWaits(d.Transmissions, d.RTO)
+	ip, err := udpExchange(ctx, conn, req, waits, func(resp []byte) (net.IP, error) {
+		if len(resp) < natpmpExternalRespLen || resp[0] != natpmpVersion || resp[1] != natpmpResponseBit|natpmpOpExternalAddr {
+			return nil, errUnrelated
+		}
+		if code := binary.BigEndian.Uint16(resp[2:]); code != 0 {
+			return nil, fmt.Errorf("gateway returned result code %d", code)
+		}
+		return net.IP(append([]byte(nil), resp[8:12]...)), nil
+	})
+	if err != nil {
+		return "", ctxErr(ctx, fmt.Errorf("natpmp: %w", err))
+	}
+	return publicGatewayIP("natpmp", ip)
+}
+
+// PCPDiscoverer learns the gateway's external IPv4 address with PCP. PCP has
+// no plain address query, so it requests a short-lived UDP mapping for the
+// discard port, reads the assigned external address and deletes the mapping.
+type PCPDiscoverer struct {
+	// Gateway is the router address (IP or IP:port); empty uses the default
+	// gateway.
+	Gateway string
+	// RTO is the initial retransmission timeout, doubled after each attempt;
+	// zero means 250ms.
+	RTO time.Duration
+	// Transmissions is the number of requests sent; zero means 4.
+	Transmissions int
+}
+
+// NewPCP returns a PCP discoverer for gateway (empty for the default gateway).
+func NewPCP(gateway string) *PCPDiscoverer {
+	return &PCPDiscoverer{Gateway: gateway}
+}
+
+// Name implements IPDiscoverer.
+func (d *PCPDiscoverer) Name() string { return "pcp" }
+
+// DiscoverIP implements IPDiscoverer. Only IPv4 is supported.
+func (d *PCPDiscoverer) DiscoverIP(ctx context.Context, family Family) (string, error) {
+	if family != IPv4 {
+		return "", fmt.Errorf("pcp: %w: %s", ErrUnsupportedFamily, family)
+	}
+	conn, err := dialGateway(ctx, d.Gateway)
+	if err != nil {
+		return "", fmt.Errorf("pcp: %w", err)
+	}
+	defer conn.Close()
+
+	var nonce [12]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", err
+	}
+	local := conn.LocalAddr().(*net.UDPAddr).IP
+	req := pcpMapRequest(local, nonce, pcpProbeLifetime)
+	waits := gatewayWaits(d.Transmissions, d.RTO)
+	ip, err := udpExchange(ctx, conn, req, waits, func(resp []byte) (net.IP, error) {
+		if len(resp) < pcpHeaderLen+pcpMapLen || resp[0] != pcpVersion || resp[1] != pcpResponseBit|pcpOpMap ||
+			[12]byte(resp[pcpHeaderLen:pcpHeaderLen+12]) != nonce {
+			return nil, errUnrelated
+		}
+		if code := resp[3]; code != 0 {
+			return nil, fmt.Errorf("gateway returned result code %d", code)
+		}
+		return net.IP(append([]byte(nil), resp[pcpHeaderLen+20:pcpHeaderLen+36]...)), nil
+	})
+	if err != nil {
+		return "", ctxErr(ctx, fmt.Errorf("pcp: %w", err))
+	}
+	// Best effort: a lifetime of zero deletes the probe mapping.
+	_, _ = conn.Write(pcpMapRequest(local, nonce, 0))
+	return publicGatewayIP("pcp", ip)
+}
+
+// pcpMapRequest encodes a PCP MAP request for the probe port.
+func pcpMapRequest(client net.IP, nonce [12]byte, lifetime uint32) []byte {
+	req := make([]byte, pcpHeaderLen+pcpMapLen)
+	req[0] = pcpVersion
+	req[1] = pcpOpMap
+	binary.BigEndian.PutUint32(req[4:], lifetime)
+	copy(req[8:24], client.To16())
+	copy(req[pcpHeaderLen:], nonce[:])
+	req[pcpHeaderLen+12] = pcpProtoUDP
+	binary.BigEndian.PutUint16(req[pcpHeaderLen+16:], pcpProbePort)
+	// Suggested external port and address stay zero: any assignment will do.
+	return req
+}
+
+// dialGateway connects a UDP socket to the NAT-PMP/PCP port of gateway, or of
+// the default gateway when it is empty.
+func dialGateway(ctx context.Context, gateway string) (net.Conn, error) {
+	if gateway == "" {
+		gw, err := DefaultGateway()
+		if err != nil {
+			return nil, err
+		}
+		gateway = gw.String()
+	}
+	if _, _, err := net.SplitHostPort(gateway); err != nil {
+		gateway = net.JoinHostPort(gateway, strconv.Itoa(natpmpPort))
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "udp4", gateway)
+}
+
+// publicGatewayIP validates an address reported by a gateway. A private or
+// CGNAT address means the gateway is itself behind NAT and its external
+// address is not the public one.
+func publicGatewayIP(source string, ip net.IP) (string, error) {
+	if ip.To4() == nil {
+		return "", fmt.Errorf("%s: gateway returned non-IPv4 address %s", source, ip)
+	}
+	if !isPublicIPv4(ip) {
+		return "", fmt.Errorf("%s: gateway reports non-public address %s (double NAT?)", source, ip)
+	}
+	return ip.To4().String(), nil
+}
+
+// gatewayWaits returns the retransmission schedule for NAT-PMP and PCP,
+// applying the defaults for non-positive values.
+func gatewayWaits(transmissions int, rto time.Duration) []time.Duration {
+	if transmissions <= 0 {
+		transmissions = natpmpDefaultTries
+	}
+	if rto <= 0 {
+		rto = natpmpDefaultRTO
+	}
+	return doublingWaits(transmissions, rto)
+}
@@ -0,0 +1,211 @@
+package netutil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// STUN message constants from RFC 5389.
+const (
+	stunHeaderLen      = 20
+	stunMagicCookie    = 0x2112A442
+	stunBindingRequest = 0x0001
+	stunBindingSuccess = 0x0101
+	stunBindingError   = 0x0111
+	stunAttrMapped     = 0x0001
+	stunAttrErrorCode  = 0x0009
+	stunAttrXORMapped  = 0x0020
+	stunFamilyIPv4     = 0x01
+	stunFamilyIPv6     = 0x02
+	stunDefaultRTO     = 500 * time.Millisecond
+	stunDefaultRc      = 7
+	stunLastWaitFactor = 16 // Rm
+)
+
+// DefaultSTUNServers are public STUN servers used when none are configured.
+var DefaultSTUNServers = []string{"stun.cloudflare.com:3478", "stun.l.google.com:19302"}
+
+// STUNDiscoverer learns the public address as the server-reflexive address
+// returned in a STUN Binding response, i.e. the address a NAT maps the host to.
+type STUNDiscoverer struct {
+	// Servers are tried in order until one answers; empty means
+	// DefaultSTUNServers.
+	Servers []string
+	// RTO is the initial retransmission timeout, doubled after each
+	// transmission; zero means 500ms.
+	RTO time.Duration
+	// Transmissions is the maximum number of requests sent to each server
+	// (Rc); zero means 7. After the last one the client waits 16*RTO.
+	// When the context has a deadline, every server but the last gets an
+	// equal share of the remaining time and its schedule is shrunk to fit.
+	Transmissions int
+}
+
+// NewSTUN returns a STUN discoverer for the given servers (host:port).
+func NewSTUN(servers ...string) *STUNDiscoverer {
+	return &STUNDiscoverer{Servers: servers}
+}
+
+// DiscoverIPv4ViaSTUN returns the public IPv4 address reported by a STUN
+// server (host:port).
+func DiscoverIPv4ViaSTUN(ctx context.Context, server string) (string, error) {
+	return NewSTUN(server).DiscoverIP(ctx, IPv4)
+}
+
+// Name implements IPDiscoverer.
+func (d *STUNDiscoverer) Name() string { return "stun" }
+
+// DiscoverIP implements IPDiscoverer. For IPv6, errors caused by the host
+// lacking an IPv6 route wrap ErrNoIPv6.
+func (d *STUNDiscoverer) DiscoverIP(ctx context.Context, family Family) (string, error) {
+	network := "udp4"
+	switch family {
+	case IPv4:
+	case IPv6:
+		network = "udp6"
+	default:
+		return "", fmt.Errorf("stun: %w: %s", ErrUnsupportedFamily, family)
+	}
+	servers := d.Servers
+	if len(servers) == 0 {
+		servers = DefaultSTUNServers
+	}
+	var errs []error
+	for i, server := range servers {
+		var budget time.Duration
+		if deadline, ok := ctx.Deadline(); ok && i < len(servers)-1 {
+			budget = time.Until(deadline) / time.Duration(len(servers)-i)
+		}
+		ip, err := d.bind(ctx, network, server, budget)
+		if err == nil {
+			return parseIP(ip, family == IPv6)
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if family == IPv6 && isNoRoute(err) {
+			err = fmt.Errorf("%w: %w", ErrNoIPv6, err)
+		}
+		errs = append(errs, fmt.Errorf("stun %s: %w", server, err))
+	}
+	return "", errors.Join(errs...)
+}
+
+// bind performs one Binding transaction with server, retransmitting the
+// request with exponential backoff as described in RFC 5389 section 7.2.1.
+// A positive budget bounds the whole schedule.
+func (d *STUNDiscoverer) bind(ctx context.Context, network, server string, budget time.Duration) (string, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, server)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	var txID [12]byte
+	if _, err := rand.Read(txID[:]); err != nil {
+		return "", err
+	}
+	req := make([]byte, stunHeaderLen)
+	binary.BigEndian.PutUint16(req[0:], stunBindingRequest)
+	binary.BigEndian.PutUint32(req[4:], stunMagicCookie)
+	copy(req[8:], txID[:])
+
+	rto := d.RTO
+	if rto <= 0 {
+		rto = stunDefaultRTO
+	}
+	rc := d.Transmissions
+	if rc <= 0 {
+		rc = stunDefaultRc
+	}
+	waits := doublingWaits(rc, rto)
+	waits[rc-1] = stunLastWaitFactor * rto
+	if budget > 0 {
+		fitWaits(waits, budget)
+	}
+	return udpExchange(ctx, conn, req, waits, func(resp []byte) (string, error) {
+		return parseSTUNResponse(resp, txID)
+	})
+}
+
+// parseSTUNResponse extracts the mapped address from a Binding response for
+// transaction txID, preferring XOR-MAPPED-ADDRESS over MAPPED-ADDRESS.
+func parseSTUNResponse(msg []byte, txID [12]byte) (string, error) {
+	if len(msg) < stunHeaderLen || binary.BigEndian.Uint32(msg[4:]) != stunMagicCookie ||
+		[12]byte(msg[8:20]) != txID {
+		return "", errUnrelated
+	}
+	typ := binary.BigEndian.Uint16(msg[0:])
+	length := int(binary.BigEndian.Uint16(msg[2:]))
+	if stunHeaderLen+length > len(msg) {
+		return "", errors.New("stun: truncated message")
+	}
+	attrs := msg[stunHeaderLen : stunHeaderLen+length]
+
+	var mapped, xorMapped net.IP
+	for len(attrs) >= 4 {
+		at := binary.BigEndian.Uint16(attrs[0:])
+		alen := int(binary.BigEndian.Uint16(attrs[2:]))
+		if 4+alen > len(attrs) {
+			return "", errors.New("stun: truncated attribute")
+		}
+		val := attrs[4 : 4+alen]
+		switch at {
+		case stunAttrXORMapped:
+			xorMapped = stunAddress(val, msg[4:20])
+		case stunAttrMapped:
+			mapped = stunAddress(val, nil)
+		case stunAttrErrorCode:
+			if typ == stunBindingError && alen >= 4 {
+				code := int(val[2]&0x7)*100 + int(val[3])
+				return "", fmt.Errorf("stun: error %d %s", code, val[4:])
+			}
+		}
+		// Attributes are padded to a multiple of four bytes.
+		attrs = attrs[min(4+(alen+3)&^3, len(attrs)):]
+	}
+	switch {
+	case typ == stunBindingError:
+		return "", errors.New("stun: error response")
+	case typ != stunBindingSuccess:
+		return "", errUnrelated
+	case xorMapped != nil:
+		return xorMapped.String(), nil
+	case mapped != nil:
+		return mapped.String(), nil
+	}
+	return "", errors.New("stun: response has no mapped address")
+}
+
+// stunAddress decodes a (XOR-)MAPPED-ADDRESS value. key is the magic cookie
+// followed by the transaction ID for XOR-MAPPED-ADDRESS, nil otherwise.
+func stunAddress(val, key []byte) net.IP {
+	if len(val) < 4 {
+		return nil
+	}
+	var size int
+	switch val[1] {
+	case stunFamilyIPv4:
+		size = net.IPv4len
+	case stunFamilyIPv6:
+		size = net.IPv6len
+	default:
+		return nil
+	}
+	if len(val) < 4+size {
+		return nil
+	}
+	ip := make(net.IP, size)
+	copy(ip, val[4:4+size])
+	for i := range key {
+		if i < size {
+			ip[i] ^= key[i]
+		}
+	}
+	return ip
+}
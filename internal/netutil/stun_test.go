@@ -0,0 +1,200 @@
+package netutil_test
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jsirianni/cloudflare-go/internal/netutil"
+	"github.com/stretchr/testify/require"
+)
+
+// stunResponder is an in-process STUN server answering Binding requests.
+type stunResponder struct {
+	conn     net.PacketConn
+	mapped   net.IP       // reported address; nil reports the sender's address
+	legacy   bool         // use MAPPED-ADDRESS instead of XOR-MAPPED-ADDRESS
+	errCode  int          // respond with a Binding error
+	foreign  bool         // send an unrelated transaction first
+	drop     atomic.Int32 // requests to ignore before answering
+	requests atomic.Int32
+}
+
+func newSTUNResponder(t *testing.T, configure ...func(*stunResponder)) *stunResponder {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &stunResponder{conn: conn}
+	for _, f := range configure {
+		f(s)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *stunResponder) addr() string { return s.conn.LocalAddr().String() }
+
+func (s *stunResponder) serve() {
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if n < 20 {
+			continue
+		}
+		s.requests.Add(1)
+		if s.drop.Add(-1) >= 0 {
+			continue
+		}
+		req := buf[:n]
+		if s.foreign {
+			other := append([]byte(nil), req[:20]...)
+			other[19] ^= 0xFF
+			s.conn.WriteTo(s.response(other, from.(*net.UDPAddr)), from)
+		}
+		s.conn.WriteTo(s.response(req, from.(*net.UDPAddr)), from)
+	}
+}
+
+func (s *stunResponder) response(req []byte, from *net.UDPAddr) []byte {
+	var attrs []byte
+	typ := uint16(0x0101)
+	if s.errCode != 0 {
+		typ = 0x0111
+		reason := "Bad Request"
+		attrs = binary.BigEndian.AppendUint16(attrs, 0x0009)
+		attrs = binary.BigEndian.AppendUint16(attrs, uint16(4+len(reason)))
+		attrs = append(attrs, 0, 0, byte(s.errCode/100), byte(s.errCode%100))
+		attrs = append(attrs, reason...)
+		for len(attrs)%4 != 0 {
+			attrs = append(attrs, 0)
+		}
+	} else {
+		ip := s.mapped
+		if ip == nil {
+			ip = from.IP
+		}
+		family, raw := byte(0x01), []byte(ip.To4())
+		if raw == nil {
+			family, raw = 0x02, []byte(ip.To16())
+		}
+		port := uint16(from.Port)
+		attrType := uint16(0x0020)
+		if s.legacy {
+			attrType = 0x0001
+		} else {
+			port ^= 0x2112
+			key := req[4:20]
+			raw = append([]byte(nil), raw...)
+			for i := range raw {
+				raw[i] ^= key[i]
+			}
+		}
+		// An unknown comprehension-optional attribute the client must skip.
+		attrs = append(attrs, 0x80, 0x22, 0x00, 0x03, 'g', 'o', '!', 0)
+		attrs = binary.BigEndian.AppendUint16(attrs, attrType)
+		attrs = binary.BigEndian.AppendUint16(attrs, uint16(4+len(raw)))
+		attrs = append(attrs, 0, family)
+		attrs = binary.BigEndian.AppendUint16(attrs, port)
+		attrs = append(attrs, raw...)
+	}
+	msg := binary.BigEndian.AppendUint16(nil, typ)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(attrs)))
+	msg = append(msg, req[4:20]...)
+	return append(msg, attrs...)
+}
+
+func TestSTUN_XORMappedAddress(t *testing.T) {
+	srv := newSTUNResponder(t)
+	ip, err := netutil.DiscoverIPv4ViaSTUN(context.Background(), srv.addr())
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", ip)
+
+	mismatched := newSTUNResponder(t, func(s *stunResponder) { s.mapped = net.ParseIP("2001:db8::5") })
+	_, err = netutil.NewSTUN(mismatched.addr()).DiscoverIP(context.Background(), netutil.IPv4)
+	require.ErrorContains(t, err, "invalid IPv4 response")
+}
+
+func TestSTUN_IPv6(t *testing.T) {
+	conn, err := net.ListenPacket("udp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback unavailable: %v", err)
+	}
+	srv := &stunResponder{conn: conn, mapped: net.ParseIP("2001:db8::5")}
+	t.Cleanup(func() { conn.Close() })
+	go srv.serve()
+
+	ip, err := netutil.NewSTUN(srv.addr()).DiscoverIP(context.Background(), netutil.IPv6)
+	require.NoError(t, err)
+	require.Equal(t, "2001:db8::5", ip)
+}
+
+func TestSTUN_MappedAddressFallback(t *testing.T) {
+	srv := newSTUNResponder(t, func(s *stunResponder) {
+		s.legacy = true
+		s.mapped = net.ParseIP("198.51.100.20")
+	})
+	ip, err := netutil.DiscoverIPv4ViaSTUN(context.Background(), srv.addr())
+	require.NoError(t, err)
+	require.Equal(t, "198.51.100.20", ip)
+}
+
+func TestSTUN_Retransmits(t *testing.T) {
+	srv := newSTUNResponder(t, func(s *stunResponder) {
+		s.drop.Store(2)
+		s.foreign = true
+	})
+	d := netutil.NewSTUN(srv.addr())
+	d.RTO = 20 * time.Millisecond
+	ip, err := d.DiscoverIP(context.Background(), netutil.IPv4)
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", ip)
+	require.Equal(t, int32(3), srv.requests.Load())
+}
+
+func TestSTUN_ErrorResponseTriesNextServer(t *testing.T) {
+	bad := newSTUNResponder(t, func(s *stunResponder) { s.errCode = 400 })
+	good := newSTUNResponder(t, func(s *stunResponder) { s.mapped = net.ParseIP("203.0.113.30") })
+
+	_, err := netutil.NewSTUN(bad.addr()).DiscoverIP(context.Background(), netutil.IPv4)
+	require.ErrorContains(t, err, "error 400 Bad Request")
+
+	ip, err := netutil.NewSTUN(bad.addr(), good.addr()).DiscoverIP(context.Background(), netutil.IPv4)
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.30", ip)
+}
+
+func TestSTUN_DeadlineSharedBetweenServers(t *testing.T) {
+	silent := newSTUNResponder(t, func(s *stunResponder) { s.drop.Store(1000) })
+	good := newSTUNResponder(t, func(s *stunResponder) { s.mapped = net.ParseIP("203.0.113.31") })
+
+	// The default schedule of the silent server alone takes 39.5s.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	ip, err := netutil.NewSTUN(silent.addr(), good.addr()).DiscoverIP(ctx, netutil.IPv4)
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.31", ip)
+	require.Equal(t, int32(7), silent.requests.Load())
+}
+
+func TestSTUN_Timeouts(t *testing.T) {
+	srv := newSTUNResponder(t, func(s *stunResponder) { s.drop.Store(1000) })
+	d := netutil.NewSTUN(srv.addr())
+	d.RTO = 5 * time.Millisecond
+	d.Transmissions = 3
+	_, err := d.DiscoverIP(context.Background(), netutil.IPv4)
+	require.ErrorContains(t, err, "no response after 3 transmissions")
+	require.Equal(t, int32(3), srv.requests.Load())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	d.RTO = time.Minute
+	_, err = d.DiscoverIP(ctx, netutil.IPv4)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,91 @@
+package netutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// maxDatagram is the receive buffer for UDP responses.
+const maxDatagram = 4096
+
+// errUnrelated marks datagrams that do not answer the pending request; the
+// exchange keeps waiting for the real answer.
+var errUnrelated = errors.New("unrelated datagram")
+
+// udpExchange sends req on conn and waits waits[i] for an answer after the
+// i-th transmission, retransmitting until an attempt succeeds or waits is
+// exhausted. parse is called for every datagram received; returning
+// errUnrelated discards it. The context aborts the exchange at any point.
+func udpExchange[T any](ctx context.Context, conn net.Conn, req []byte, waits []time.Duration, parse func([]byte) (T, error)) (T, error) {
+	var zero T
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	buf := make([]byte, maxDatagram)
+	for _, wait := range waits {
+		if _, err := conn.Write(req); err != nil {
+			return zero, ctxErr(ctx, err)
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(wait))
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				var ne net.Error
+				if errors.As(err, &ne) && ne.Timeout() && ctx.Err() == nil {
+					break // retransmit
+				}
+				return zero, ctxErr(ctx, err)
+			}
+			v, err := parse(buf[:n])
+			if errors.Is(err, errUnrelated) {
+				continue
+			}
+			return v, err
+		}
+	}
+	return zero, fmt.Errorf("no response after %d transmissions", len(waits))
+}
+
+// fixedWaits returns n identical retransmission timeouts.
+func fixedWaits(n int, d time.Duration) []time.Duration {
+	waits := make([]time.Duration, n)
+	for i := range waits {
+		waits[i] = d
+	}
+	return waits
+}
+
+// doublingWaits returns n timeouts starting at d and doubling each time.
+func doublingWaits(n int, d time.Duration) []time.Duration {
+	waits := make([]time.Duration, n)
+	for i := range waits {
+		waits[i] = d << i
+	}
+	return waits
+}
+
+// fitWaits scales waits down proportionally so that they add up to at most
+// total.
+func fitWaits(waits []time.Duration, total time.Duration) {
+	var sum time.Duration
+	for _, w := range waits {
+		sum += w
+	}
+	if sum <= total {
+		return
+	}
+	for i, w := range waits {
+		waits[i] = time.Duration(float64(w) * float64(total) / float64(sum))
+	}
+}
+
+// ctxErr prefers the context's error over the I/O error it caused.
+func ctxErr(ctx context.Context, err error) error {
+	if cerr := ctx.Err(); cerr != nil {
+		return cerr
+	}
+	return err
+}
@@ -0,0 +1,250 @@
+package netutil
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// UPnP IGD constants.
+const (
+	ssdpMulticastAddr = "239.255.255.250:1900"
+	ssdpDefaultWait   = 2 * time.Second
+	upnpMaxBody       = 1 << 20
+	upnpSOAPEnvelope  = `<?xml version="1.0"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+		`<s:Body><u:GetExternalIPAddress xmlns:u="%s"></u:GetExternalIPAddress></s:Body></s:Envelope>`
+)
+
+// ssdpSearchTargets are the device types searched for, newest first.
+var ssdpSearchTargets = []string{
+	"urn:schemas-upnp-org:device:InternetGatewayDevice:2",
+	"urn:schemas-upnp-org:device:InternetGatewayDevice:1",
+}
+
+// upnpWANServices are the service types that implement GetExternalIPAddress.
+var upnpWANServices = []string{
+	"urn:schemas-upnp-org:service:WANIPConnection:",
+	"urn:schemas-upnp-org:service:WANPPPConnection:",
+}
+
+// UPnPDiscoverer asks a UPnP Internet Gateway Device for its external IPv4
+// address with the GetExternalIPAddress SOAP action. The gateway is found via
+// SSDP unless Location is set.
+type UPnPDiscoverer struct {
+	// Location is the gateway's device description URL; empty discovers it
+	// via SSDP.
+	Location string
+	// SSDPAddr is where M-SEARCH requests are sent; empty means the SSDP
+	// multicast group.
+	SSDPAddr string
+	// SearchWait bounds the SSDP search; zero means 2s.
+	SearchWait time.Duration
+	// Client performs the HTTP requests; nil uses a client with a 5s timeout.
+	Client *http.Client
+}
+
+// NewUPnP returns a UPnP IGD discoverer; location may be empty to use SSDP.
+func NewUPnP(location string) *UPnPDiscoverer {
+	return &UPnPDiscoverer{Location: location}
+}
+
+// Name implements IPDiscoverer.
+func (d *UPnPDiscoverer) Name() string { return "upnp" }
+
+// DiscoverIP implements IPDiscoverer. Only IPv4 is supported.
+func (d *UPnPDiscoverer) DiscoverIP(ctx context.Context, family Family) (string, error) {
+	if family != IPv4 {
+		return "", fmt.Errorf("upnp: %w: %s", ErrUnsupportedFamily, family)
+	}
+	location := d.Location
+	if location == "" {
+		var err error
+		if location, err = d.search(ctx); err != nil {
+			return "", fmt.Errorf("upnp: %w", err)
+		}
+	}
+	client := d.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	serviceType, controlURL, err := upnpControlURL(ctx, client, location)
+	if err != nil {
+		return "", fmt.Errorf("upnp: %w", err)
+	}
+	ip, err := upnpExternalIP(ctx, client, serviceType, controlURL)
+	if err != nil {
+		return "", fmt.Errorf("upnp: %w", err)
+	}
+	return publicGatewayIP("upnp", ip)
+}
+
+// search sends SSDP M-SEARCH requests and returns the LOCATION of the first
+// gateway that answers.
+func (d *UPnPDiscoverer) search(ctx context.Context) (string, error) {
+	target := d.SSDPAddr
+	if target == "" {
+		target = ssdpMulticastAddr
+	}
+	raddr, err := net.ResolveUDPAddr("udp4", target)
+	if err != nil {
+		return "", err
+	}
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	wait := d.SearchWait
+	if wait <= 0 {
+		wait = ssdpDefaultWait
+	}
+	for _, st := range ssdpSearchTargets {
+		msg := "M-SEARCH * HTTP/1.1\r\n" +
+			"HOST: " + ssdpMulticastAddr + "\r\n" +
+			"MAN: \"ssdp:discover\"\r\n" +
+			fmt.Sprintf("MX: %d\r\n", max(int(wait/time.Second), 1)) +
+			"ST: " + st + "\r\n\r\n"
+		if _, err := conn.WriteTo([]byte(msg), raddr); err != nil {
+			return "", ctxErr(ctx, err)
+		}
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(wait))
+	buf := make([]byte, maxDatagram)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() && ctx.Err() == nil {
+				return "", errors.New("no internet gateway device answered the SSDP search")
+			}
+			return "", ctxErr(ctx, err)
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		if loc := resp.Header.Get("Location"); resp.StatusCode == http.StatusOK && loc != "" {
+			return loc, nil
+		}
+	}
+}
+
+// upnpDevice is the subset of a UPnP device description used to find the
+// WAN connection service.
+type upnpDevice struct {
+	Services []struct {
+		ServiceType string `xml:"serviceType"`
+		ControlURL  string `xml:"controlURL"`
+	} `xml:"serviceList>service"`
+	Devices []upnpDevice `xml:"deviceList>device"`
+}
+
+// upnpControlURL fetches the device description at location and returns the
+// type and absolute control URL of its WAN connection service.
+func upnpControlURL(ctx context.Context, client *http.Client, location string) (string, string, error) {
+	base, err := url.Parse(location)
+	if err != nil {
+		return "", "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("device description: %s", resp.Status)
+	}
+	var root struct {
+		URLBase string     `xml:"URLBase"`
+		Device  upnpDevice `xml:"device"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, upnpMaxBody)).Decode(&root); err != nil {
+		return "", "", fmt.Errorf("device description: %w", err)
+	}
+	if root.URLBase != "" {
+		if base, err = url.Parse(root.URLBase); err != nil {
+			return "", "", err
+		}
+	}
+	serviceType, control := findWANService(root.Device)
+	if control == "" {
+		return "", "", errors.New("gateway has no WANIPConnection or WANPPPConnection service")
+	}
+	ref, err := url.Parse(control)
+	if err != nil {
+		return "", "", err
+	}
+	return serviceType, base.ResolveReference(ref).String(), nil
+}
+
+// findWANService searches dev and its embedded devices depth-first.
+func findWANService(dev upnpDevice) (string, string) {
+	for _, s := range dev.Services {
+		for _, prefix := range upnpWANServices {
+			if strings.HasPrefix(s.ServiceType, prefix) {
+				return s.ServiceType, s.ControlURL
+			}
+		}
+	}
+	for _, child := range dev.Devices {
+		if st, c := findWANService(child); c != "" {
+			return st, c
+		}
+	}
+	return "", ""
+}
+
+// upnpExternalIP invokes GetExternalIPAddress on the control URL.
+func upnpExternalIP(ctx context.Context, client *http.Client, serviceType, controlURL string) (net.IP, error) {
+	body := fmt.Sprintf(upnpSOAPEnvelope, serviceType)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, controlURL, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", `"`+serviceType+`#GetExternalIPAddress"`)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GetExternalIPAddress: %s", resp.Status)
+	}
+	dec := xml.NewDecoder(io.LimitReader(resp.Body, upnpMaxBody))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, errors.New("GetExternalIPAddress: response has no NewExternalIPAddress")
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "NewExternalIPAddress" {
+			var v string
+			if err := dec.DecodeElement(&v, &se); err != nil {
+				return nil, err
+			}
+			ip := net.ParseIP(strings.TrimSpace(v))
+			if ip == nil {
+				return nil, fmt.Errorf("GetExternalIPAddress: invalid address %q", v)
+			}
+			return ip, nil
+		}
+	}
+}
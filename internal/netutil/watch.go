@@ -0,0 +1,36 @@
+package netutil
+
+import (
+	"context"
+	"time"
+)
+
+// Debounce forwards a signal from in once no further signal has arrived for
+// quiet, collapsing bursts such as a flapping link into one event. The
+// returned channel is closed when ctx is done or in is closed.
+func Debounce(ctx context.Context, in <-chan struct{}, quiet time.Duration) <-chan struct{} {
+	out := make(chan struct{}, 1)
+	go func() {
+		defer close(out)
+		timer := time.NewTimer(quiet)
+		timer.Stop()
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-in:
+				if !ok {
+					return
+				}
+				timer.Reset(quiet)
+			case <-timer.C:
+				select {
+				case out <- struct{}{}:
+				default: // a signal is already pending
+				}
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,153 @@
+//go:build linux
+
+package netutil
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+)
+
+// rtnetlink multicast groups from linux/rtnetlink.h.
+const (
+	rtmgrpLink       = 0x1
+	rtmgrpIPv4IfAddr = 0x10
+	rtmgrpIPv4Route  = 0x40
+	rtmgrpIPv6IfAddr = 0x100
+	rtmgrpIPv6Route  = 0x400
+
+	// netlinkGroups selects address, route and link change notifications.
+	netlinkGroups = rtmgrpLink | rtmgrpIPv4IfAddr | rtmgrpIPv4Route | rtmgrpIPv6IfAddr | rtmgrpIPv6Route
+)
+
+// WatchAddressChanges subscribes to rtnetlink notifications and signals on the
+// returned channel whenever an address, route or link of iface (any interface
+// when empty) is added or removed, or when notifications were lost to a
+// receive buffer overflow. Signals are coalesced, not queued; wrap the channel
+// with Debounce to rate-limit reactions. The channel is closed when ctx is done
+// or the socket fails.
+func WatchAddressChanges(ctx context.Context, iface string) (<-chan struct{}, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC|syscall.SOCK_NONBLOCK, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return nil, fmt.Errorf("netlink socket: %w", err)
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: netlinkGroups}); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("netlink bind: %w", err)
+	}
+	// Wrapping the non-blocking socket in an os.File registers it with the
+	// runtime poller, so Close unblocks a pending Read.
+	f := os.NewFile(uintptr(fd), "netlink")
+	stop := context.AfterFunc(ctx, func() { f.Close() })
+
+	out := make(chan struct{}, 1)
+	go func() {
+		defer close(out)
+		defer stop()
+		defer f.Close()
+		readNetlinkEvents(f, iface, interfaceName, out)
+	}()
+	return out, nil
+}
+
+// readNetlinkEvents signals out for every batch of messages read from r that
+// concerns iface, until r fails. ENOBUFS means the kernel dropped messages
+// because the receive buffer overflowed, as happens during reconnect bursts;
+// a change may have been among them, so it is signaled and reading goes on.
+func readNetlinkEvents(r io.Reader, iface string, names func(int) string, out chan<- struct{}) {
+	signal := func() {
+		select {
+		case out <- struct{}{}:
+		default:
+		}
+	}
+	buf := make([]byte, os.Getpagesize()*4)
+	for {
+		n, err := r.Read(buf)
+		if errors.Is(err, syscall.ENOBUFS) {
+			signal()
+			continue
+		}
+		if err != nil {
+			return
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			continue
+		}
+		if netlinkEventMatches(msgs, iface, names) {
+			signal()
+		}
+	}
+}
+
+// interfaceName resolves an interface index, returning "" if it is gone.
+func interfaceName(index int) string {
+	ifc, err := net.InterfaceByIndex(index)
+	if err != nil {
+		return ""
+	}
+	return ifc.Name
+}
+
+// netlinkEventMatches reports whether any message is an address, route or
+// link change concerning iface. Interfaces are compared by name because
+// PPP links get a new index on every reconnect; an index that no longer
+// resolves counts as a match.
+func netlinkEventMatches(msgs []syscall.NetlinkMessage, iface string, names func(int) string) bool {
+	for i := range msgs {
+		m := &msgs[i]
+		index := -1
+		switch m.Header.Type {
+		case syscall.RTM_NEWADDR, syscall.RTM_DELADDR:
+			// struct ifaddrmsg: family, prefixlen, flags, scope, index.
+			if len(m.Data) >= syscall.SizeofIfAddrmsg {
+				index = int(binary.NativeEndian.Uint32(m.Data[4:8]))
+			}
+		case syscall.RTM_NEWLINK, syscall.RTM_DELLINK:
+			attrs, err := syscall.ParseNetlinkRouteAttr(m)
+			if err != nil {
+				continue
+			}
+			for _, a := range attrs {
+				if a.Attr.Type == syscall.IFLA_IFNAME {
+					name := string(a.Value)
+					if n := len(name); n > 0 && name[n-1] == 0 {
+						name = name[:n-1]
+					}
+					if iface == "" || name == iface {
+						return true
+					}
+				}
+			}
+			continue
+		case syscall.RTM_NEWROUTE, syscall.RTM_DELROUTE:
+			attrs, err := syscall.ParseNetlinkRouteAttr(m)
+			if err != nil {
+				continue
+			}
+			for _, a := range attrs {
+				if a.Attr.Type == syscall.RTA_OIF && len(a.Value) >= 4 {
+					index = int(binary.NativeEndian.Uint32(a.Value))
+				}
+			}
+		default:
+			continue
+		}
+		if index < 0 {
+			continue
+		}
+		if iface == "" {
+			return true
+		}
+		if name := names(index); name == "" || name == iface {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+//go:build linux
+
+package netutil
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rtAttr encodes a netlink route attribute padded to four bytes.
+func rtAttr(typ uint16, value []byte) []byte {
+	b := binary.NativeEndian.AppendUint16(nil, uint16(syscall.SizeofRtAttr+len(value)))
+	b = binary.NativeEndian.AppendUint16(b, typ)
+	b = append(b, value...)
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func nlMsg(typ uint16, data []byte) syscall.NetlinkMessage {
+	return syscall.NetlinkMessage{Header: syscall.NlMsghdr{Type: typ}, Data: data}
+}
+
+func TestNetlinkEventMatches(t *testing.T) {
+	names := func(index int) string {
+		return map[int]string{2: "eth0", 7: "ppp0"}[index]
+	}
+	addr := func(typ uint16, index uint32) syscall.NetlinkMessage {
+		data := make([]byte, syscall.SizeofIfAddrmsg)
+		binary.NativeEndian.PutUint32(data[4:], index)
+		return nlMsg(typ, data)
+	}
+	route := func(oif uint32) syscall.NetlinkMessage {
+		data := make([]byte, syscall.SizeofRtMsg)
+		data = append(data, rtAttr(syscall.RTA_OIF, binary.NativeEndian.AppendUint32(nil, oif))...)
+		return nlMsg(syscall.RTM_NEWROUTE, data)
+	}
+	link := func(name string) syscall.NetlinkMessage {
+		data := make([]byte, syscall.SizeofIfInfomsg)
+		data = append(data, rtAttr(syscall.IFLA_IFNAME, append([]byte(name), 0))...)
+		return nlMsg(syscall.RTM_NEWLINK, data)
+	}
+
+	cases := []struct {
+		name  string
+		msgs  []syscall.NetlinkMessage
+		iface string
+		want  bool
+	}{
+		{"new address on watched iface", []syscall.NetlinkMessage{addr(syscall.RTM_NEWADDR, 7)}, "ppp0", true},
+		{"deleted address on other iface", []syscall.NetlinkMessage{addr(syscall.RTM_DELADDR, 2)}, "ppp0", false},
+		{"address on vanished iface", []syscall.NetlinkMessage{addr(syscall.RTM_DELADDR, 99)}, "ppp0", true},
+		{"any iface", []syscall.NetlinkMessage{addr(syscall.RTM_NEWADDR, 2)}, "", true},
+		{"route via watched iface", []syscall.NetlinkMessage{route(7)}, "ppp0", true},
+		{"route via other iface", []syscall.NetlinkMessage{route(2)}, "ppp0", false},
+		{"link of watched iface", []syscall.NetlinkMessage{link("ppp0")}, "ppp0", true},
+		{"link of other iface", []syscall.NetlinkMessage{link("eth0")}, "ppp0", false},
+		{"unrelated message", []syscall.NetlinkMessage{nlMsg(syscall.RTM_NEWNEIGH, nil)}, "", false},
+		{"match later in batch", []syscall.NetlinkMessage{route(2), addr(syscall.RTM_NEWADDR, 7)}, "ppp0", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, netlinkEventMatches(tc.msgs, tc.iface, names))
+		})
+	}
+}
+
+func TestWatchAddressChanges_ClosesOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := WatchAddressChanges(ctx, "lo")
+	if err != nil {
+		t.Skipf("netlink unavailable: %v", err)
+	}
+	cancel()
+	require.Eventually(t, func() bool {
+		select {
+		case _, ok := <-events:
+			return !ok
+		default:
+			return false
+		}
+	}, time.Second, 5*time.Millisecond)
+}
+
+// scriptedReader replays reads: each step is either a raw netlink message
+// batch or an error.
+type scriptedReader struct {
+	steps []any
+}
+
+func (r *scriptedReader) Read(p []byte) (int, error) {
+	if len(r.steps) == 0 {
+		return 0, os.ErrClosed
+	}
+	step := r.steps[0]
+	r.steps = r.steps[1:]
+	if err, ok := step.(error); ok {
+		return 0, err
+	}
+	return copy(p, step.([]byte)), nil
+}
+
+// rawAddrMsg encodes an RTM_NEWADDR message for the interface index.
+func rawAddrMsg(index uint32) []byte {
+	data := make([]byte, syscall.SizeofIfAddrmsg)
+	binary.NativeEndian.PutUint32(data[4:], index)
+	b := binary.NativeEndian.AppendUint32(nil, uint32(syscall.NLMSG_HDRLEN+len(data)))
+	b = binary.NativeEndian.AppendUint16(b, syscall.RTM_NEWADDR)
+	b = append(b, make([]byte, syscall.NLMSG_HDRLEN-6)...)
+	return append(b, data...)
+}
+
+func TestReadNetlinkEvents(t *testing.T) {
+	names := func(index int) string {
+		return map[int]string{2: "eth0", 7: "ppp0"}[index]
+	}
+	overflow := &os.SyscallError{Syscall: "read", Err: syscall.ENOBUFS}
+
+	cases := []struct {
+		name  string
+		steps []any
+		want  int
+		// left is the number of steps never read.
+		left int
+	}{
+		{"matching event", []any{rawAddrMsg(7)}, 1, 0},
+		{"other iface", []any{rawAddrMsg(2)}, 0, 0},
+		{"overflow signals a re-sync", []any{overflow, rawAddrMsg(2)}, 1, 0},
+		{"keeps reading after overflow", []any{overflow, rawAddrMsg(7)}, 2, 0},
+		{"stops on other errors", []any{syscall.EBADF, rawAddrMsg(7)}, 0, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &scriptedReader{steps: tc.steps}
+			// Room for a signal per step, so none are coalesced.
+			out := make(chan struct{}, len(tc.steps))
+			readNetlinkEvents(r, "ppp0", names, out)
+			require.Len(t, out, tc.want)
+			require.Len(t, r.steps, tc.left)
+		})
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package netutil
+
+import (
+	"context"
+	"errors"
+)
+
+// WatchAddressChanges is only implemented on Linux, where it uses rtnetlink.
+func WatchAddressChanges(context.Context, string) (<-chan struct{}, error) {
+	return nil, errors.New("watching address changes is only supported on Linux")
+}
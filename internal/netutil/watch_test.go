@@ -0,0 +1,45 @@
+package netutil_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jsirianni/cloudflare-go/internal/netutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebounce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan struct{})
+	out := netutil.Debounce(ctx, in, 30*time.Millisecond)
+
+	// A burst collapses into a single signal after the quiet period.
+	for range 5 {
+		in <- struct{}{}
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case <-out:
+	case <-time.After(time.Second):
+		t.Fatal("expected a debounced signal")
+	}
+	select {
+	case <-out:
+		t.Fatal("burst produced more than one signal")
+	case <-time.After(60 * time.Millisecond):
+	}
+
+	in <- struct{}{}
+	select {
+	case <-out:
+	case <-time.After(time.Second):
+		t.Fatal("expected a second signal")
+	}
+
+	cancel()
+	require.Eventually(t, func() bool {
+		_, ok := <-out
+		return !ok
+	}, time.Second, 5*time.Millisecond)
+}